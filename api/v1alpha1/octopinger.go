@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -22,6 +23,11 @@ func init() {
 // Octopinger is the Schema for the octopinger API
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Agents",type=string,JSONPath=`.status.readyAgents`
+// +kubebuilder:printcolumn:name="Degraded Nodes",type=integer,JSONPath=`.status.connectivity.degradedNodes`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +operator-sdk:csv:customresourcedefinitions:resources={{Octopinger,v1alpha1,""}}
 // +operator-sdk:csv:customresourcedefinitions:resources={{Pod,v1,""}}
 // +operator-sdk:csv:customresourcedefinitions:resources={{ReplicaSet,v1,""}}
@@ -37,6 +43,7 @@ type Octopinger struct {
 // +k8s:openapi-gen=true
 type OctopingerSpec struct {
 	// Label is the value of the 'octopinger=' label to set on a node that should run Octopinger.
+	// If it is empty, Octopinger runs on all nodes.
 	Label string `json:"label"`
 
 	// Config is a wrapper to contain the configuration for Octopinger.
@@ -46,13 +53,108 @@ type OctopingerSpec struct {
 	Template Template `json:"template"`
 }
 
+// Network is the network of the nodes to probe.
+// +kubebuilder:validation:Enum=host;pod;both
+type Network string
+
+const (
+	// NetworkHost is probing the host IPs of the nodes.
+	NetworkHost Network = "host"
+	// NetworkPod is probing the pod IPs of the Octopinger instances.
+	NetworkPod Network = "pod"
+	// NetworkBoth is probing the host and the pod IPs.
+	NetworkBoth Network = "both"
+)
+
+// IPFamily is the family of the probed addresses.
+// +kubebuilder:validation:Enum=IPv4;IPv6
+type IPFamily string
+
+const (
+	// IPFamilyIPv4 is probing the IPv4 addresses.
+	IPFamilyIPv4 IPFamily = "IPv4"
+	// IPFamilyIPv6 is probing the IPv6 addresses.
+	IPFamilyIPv6 IPFamily = "IPv6"
+)
+
+// DNSRecordType is the type of the records to query.
+// +kubebuilder:validation:Enum=A;AAAA;CNAME;SRV;MX;TXT;PTR
+type DNSRecordType string
+
+const (
+	// DNSRecordTypeA is querying the IPv4 addresses of a name.
+	DNSRecordTypeA DNSRecordType = "A"
+	// DNSRecordTypeAAAA is querying the IPv6 addresses of a name.
+	DNSRecordTypeAAAA DNSRecordType = "AAAA"
+	// DNSRecordTypeCNAME is querying the canonical name of an alias.
+	DNSRecordTypeCNAME DNSRecordType = "CNAME"
+	// DNSRecordTypeSRV is querying the services of a name.
+	DNSRecordTypeSRV DNSRecordType = "SRV"
+	// DNSRecordTypeMX is querying the mail exchanges of a name.
+	DNSRecordTypeMX DNSRecordType = "MX"
+	// DNSRecordTypeTXT is querying the text records of a name.
+	DNSRecordTypeTXT DNSRecordType = "TXT"
+	// DNSRecordTypePTR is querying the name of an IP address.
+	DNSRecordTypePTR DNSRecordType = "PTR"
+)
+
+// DNSResolvConf is the resolv.conf of the search path probe.
+// +kubebuilder:validation:Enum=pod;node
+type DNSResolvConf string
+
+const (
+	// DNSResolvConfPod is reading the resolv.conf of the Octopinger pod, which has the search list of the cluster.
+	DNSResolvConfPod DNSResolvConf = "pod"
+	// DNSResolvConfNode is reading the resolv.conf of the node, which is mounted into the Octopinger pod.
+	// The resolv.conf of systemd-resolved is preferred, if present.
+	DNSResolvConfNode DNSResolvConf = "node"
+)
+
+// ICMPSocket is the kind of the sockets of the ICMP and MTU probes.
+// +kubebuilder:validation:Enum=auto;datagram;raw
+type ICMPSocket string
+
+const (
+	// ICMPSocketAuto is using datagram ping sockets if the group of Octopinger is in net.ipv4.ping_group_range, and raw sockets otherwise.
+	ICMPSocketAuto ICMPSocket = "auto"
+	// ICMPSocketDatagram is using unprivileged datagram ping sockets, which do not need the NET_RAW capability.
+	ICMPSocketDatagram ICMPSocket = "datagram"
+	// ICMPSocketRaw is using raw sockets, which need the NET_RAW capability.
+	ICMPSocketRaw ICMPSocket = "raw"
+)
+
 // Config is a wrapper to contain the configuration of Octopinger.
 type Config struct {
+	// Network is the network of the nodes to probe, "host", "pod" or "both". The default is "host".
+	Network Network `json:"network,omitempty"`
+
+	// IPFamilies are the IP families to probe, "IPv4" and "IPv6".
+	// The default is the families of the pod IPs of Octopinger.
+	IPFamilies []IPFamily `json:"ip_families,omitempty"`
+
 	// ICMP is the configuration for the ICMP probe.
 	ICMP ICMP `json:"icmp"`
 
 	// DNS is the configuration for the DNS probe.
 	DNS DNS `json:"dns"`
+
+	// TCP is the configuration for the TCP probe.
+	TCP TCP `json:"tcp,omitempty"`
+
+	// HTTP is the configuration for the HTTP probe.
+	HTTP HTTP `json:"http,omitempty"`
+
+	// TLS is the configuration for the TLS probe.
+	TLS TLS `json:"tls,omitempty"`
+
+	// Service is the configuration for the Service probe.
+	Service Service `json:"service,omitempty"`
+
+	// MTU is the configuration for the MTU probe.
+	MTU MTU `json:"mtu,omitempty"`
+
+	// Histograms is the configuration for the latency histograms of the probes.
+	Histograms Histograms `json:"histograms,omitempty"`
 }
 
 // DNS configures this probe.
@@ -62,9 +164,59 @@ type DNS struct {
 	// Names contains the list of domain names to query.
 	Names []string `json:"names,omitempty"`
 	// Server contains a domain name servers to use for the probe. By default the configured DNS servers are used.
+	// The server is queried over DNS-over-TLS with "dot://host[:port]" and over DNS-over-HTTPS with "https://host/path".
 	Server string `json:"server,omitempty"`
-	// Timeout the time to wait for the probe to succeed. The default is "1m" (1 minute).
+	// TLS configures the verification of DNS-over-TLS and DNS-over-HTTPS servers.
+	TLS DNSTLS `json:"tls,omitempty"`
+	// Timeout the time to wait for the probe to succeed. The default is "3s" (3 seconds).
 	Timeout string `json:"timeout,omitempty"`
+	// Interval is the time between two rounds of the probe. The default is "1s" (1 second).
+	Interval string `json:"interval,omitempty"`
+	// Checks contains the list of queries with validation of the answers.
+	Checks []DNSCheck `json:"checks,omitempty"`
+	// Endpoints is resolving the names at each endpoint of the cluster DNS Service directly,
+	// instead of the server, so that a single unhealthy replica is not masked by retries.
+	Endpoints bool `json:"endpoints,omitempty"`
+	// Service is the "namespace/name" of the cluster DNS Service. The default is "kube-system/kube-dns".
+	Service string `json:"service,omitempty"`
+	// SearchPath is resolving names through the search list of a resolv.conf like a pod,
+	// which reports the number of queries and the total time of each lookup.
+	SearchPath DNSSearchPath `json:"search_path,omitempty"`
+}
+
+// DNSSearchPath configures the resolution of names through the search list of a resolv.conf.
+type DNSSearchPath struct {
+	// Names contains the short names (e.g. "kubernetes.default") and the fully qualified names with a trailing dot to resolve.
+	Names []string `json:"names,omitempty"`
+	// ResolvConf is the resolv.conf with the search list, the ndots option and the name servers, "pod" or "node". The default is "pod".
+	ResolvConf DNSResolvConf `json:"resolv_conf,omitempty"`
+}
+
+// DNSCheck is a DNS query with validation of the answers.
+type DNSCheck struct {
+	// Name is the domain name to query, or the IP address for PTR records.
+	Name string `json:"name"`
+	// Type is the type of the records to query. The default is "A".
+	Type DNSRecordType `json:"type,omitempty"`
+	// ExpectedAnswers are the answers that have to be in the response, in the presentation format of dig
+	// (e.g. "10.0.0.1" for A, "10 mail.example.com" for MX or "0 5 443 www.example.com" for SRV records).
+	ExpectedAnswers []string `json:"expected_answers,omitempty"`
+	// ExpectedAnswerCount is the number of answers of the response. By default at least one answer is expected.
+	ExpectedAnswerCount int `json:"expected_answer_count,omitempty"`
+	// Server is the domain name server to query for this check. The default is the server of the probe.
+	// The "dot://" and "https://" schemes are supported as for the server of the probe.
+	Server string `json:"server,omitempty"`
+}
+
+// DNSTLS configures the verification of encrypted DNS servers.
+type DNSTLS struct {
+	// ServerName is the name to send with SNI and to verify the certificate. By default the host of the server is used.
+	ServerName string `json:"server_name,omitempty"`
+	// CAConfigMap is the key of a ConfigMap in the namespace of the Octopinger with the PEM encoded certificate authorities
+	// to verify the chain, which is mounted into the pods. By default the system roots are used.
+	CAConfigMap *corev1.ConfigMapKeySelector `json:"ca_config_map,omitempty"`
+	// InsecureSkipVerify is disabling the verification of the certificate.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
 }
 
 // ICMP configures this probe.
@@ -75,12 +227,117 @@ type ICMP struct {
 	ExcludeNodes []string `json:"exclude_nodes,omitempty"`
 	// AdditionalTargets this is a list of additional targets to probe via ICMP.
 	AdditionalTargets []string ` json:"additionaltargets,omitempty"`
-	// Timeout the time to wait for the probe to succeed. The default is "1m" (1 minute).
+	// Timeout the time to wait for the probe to succeed. The default is "5s" (5 seconds).
 	Timeout string `json:"timeout,omitempty"`
 	// Count is number of ICMP packets to send.
 	Count int `json:"count,omitempty"`
 	// NodePacketLossThreshold determines the threshold to report a node as available or not (Default: "0.05")
 	NodePacketLossThreshold string `json:"node_packet_loss_treshold,omitempty"`
+	// Interval is the time between two rounds of the probe. The default is "1s" (1 second).
+	Interval string `json:"interval,omitempty"`
+	// Socket is the kind of the sockets of the ICMP and MTU probes, "auto", "datagram" or "raw". The default is "auto".
+	// With "datagram" the operator allows the group of Octopinger in net.ipv4.ping_group_range of the pods and does not add the NET_RAW capability.
+	Socket ICMPSocket `json:"socket,omitempty"`
+}
+
+// HTTP configures this probe.
+type HTTP struct {
+	// Enable is turning the HTTP probe on for Octopinger.
+	Enable bool `json:"enable"`
+	// Targets contains the list of HTTP(S) endpoints to request.
+	Targets []HTTPTarget `json:"targets,omitempty"`
+	// Interval is the time between two rounds of the probe. The default is "1s" (1 second).
+	Interval string `json:"interval,omitempty"`
+}
+
+// HTTPTarget is an endpoint to request by the HTTP probe.
+type HTTPTarget struct {
+	// URL is the HTTP(S) URL to request.
+	URL string `json:"url"`
+	// Method is the HTTP method of the request. The default is "GET".
+	Method string `json:"method,omitempty"`
+	// Headers are the additional headers to send with the request.
+	Headers map[string]string `json:"headers,omitempty"`
+	// ExpectedStatusCodes are the status codes of a successful response. By default all 2xx status codes are successful.
+	ExpectedStatusCodes []int `json:"expected_status_codes,omitempty"`
+	// BodyRegex is a regular expression the response body has to match.
+	BodyRegex string `json:"body_regex,omitempty"`
+	// Timeout the time to wait for the response. The default is "5s" (5 seconds).
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Service configures this probe.
+type Service struct {
+	// Enable is turning the Service probe on for Octopinger. This creates a ClusterIP and a headless Service for the Octopinger instances.
+	Enable bool `json:"enable"`
+	// Timeout the time to wait for the response of a backend. The default is "5s" (5 seconds).
+	Timeout string `json:"timeout,omitempty"`
+	// Interval is the time between two rounds of the probe. The default is "1s" (1 second).
+	Interval string `json:"interval,omitempty"`
+}
+
+// MTU configures this probe.
+type MTU struct {
+	// Enable is turning the MTU probe on for Octopinger. The probe sends ICMP echo requests with the don't fragment bit to all nodes.
+	Enable bool `json:"enable"`
+	// Sizes contains the list of IP packet sizes in bytes to probe.
+	Sizes []int `json:"sizes,omitempty"`
+	// Search is searching the path MTU to each node, up to the expected MTU.
+	Search bool `json:"search,omitempty"`
+	// Expected is the expected MTU of the paths to the nodes. The default is 1500.
+	Expected int `json:"expected,omitempty"`
+	// Timeout the time to wait for an echo reply. The default is "1s" (1 second).
+	Timeout string `json:"timeout,omitempty"`
+	// Interval is the time between two rounds of the probe. The default is "1s" (1 second).
+	Interval string `json:"interval,omitempty"`
+}
+
+// Histograms configures the latency histograms of the probes.
+type Histograms struct {
+	// Buckets contains the upper bounds of the buckets in seconds (e.g. "0.001").
+	// The default are exponential buckets from 100µs to about 1.6s.
+	Buckets []string `json:"buckets,omitempty"`
+	// Native is exposing native histograms with sparse buckets in addition to the classic buckets.
+	Native bool `json:"native,omitempty"`
+}
+
+// TCP configures this probe.
+type TCP struct {
+	// Enable is turning the TCP probe on for Octopinger.
+	Enable bool `json:"enable"`
+	// Targets contains the list of host:port addresses to connect to.
+	Targets []string `json:"targets,omitempty"`
+	// NodePort is the port to connect to on all nodes. By default the nodes are not probed.
+	NodePort int `json:"node_port,omitempty"`
+	// Timeout the time to wait for the connection to be established. The default is "5s" (5 seconds).
+	Timeout string `json:"timeout,omitempty"`
+	// Interval is the time between two rounds of the probe. The default is "1s" (1 second).
+	Interval string `json:"interval,omitempty"`
+}
+
+// TLS configures this probe.
+type TLS struct {
+	// Enable is turning the TLS probe on for Octopinger.
+	Enable bool `json:"enable"`
+	// APIServer is adding the Kubernetes API server to the targets.
+	APIServer bool `json:"api_server,omitempty"`
+	// Targets contains the list of TLS endpoints to connect to.
+	Targets []TLSTarget `json:"targets,omitempty"`
+	// Interval is the time between two rounds of the probe. The default is "1s" (1 second).
+	Interval string `json:"interval,omitempty"`
+}
+
+// TLSTarget is an endpoint to connect to by the TLS probe.
+type TLSTarget struct {
+	// Address is the host:port address to connect to.
+	Address string `json:"address"`
+	// ServerName is the name to send with SNI and to verify the certificate. By default the host of the address is used.
+	ServerName string `json:"server_name,omitempty"`
+	// CAConfigMap is the key of a ConfigMap in the namespace of the Octopinger with the PEM encoded certificate authorities
+	// to verify the chain, which is mounted into the pods. By default the system roots are used.
+	CAConfigMap *corev1.ConfigMapKeySelector `json:"ca_config_map,omitempty"`
+	// Timeout the time to wait for the handshake. The default is "5s" (5 seconds).
+	Timeout string `json:"timeout,omitempty"`
 }
 
 // Template ...
@@ -90,6 +347,43 @@ type Template struct {
 
 	// Tolerations ...
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// NodeSelector is selecting the nodes to run Octopinger on, in addition to the label.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Affinity is the scheduling constraints of the Octopinger pods.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// ImagePullPolicy is the pull policy of the image. The default is "Always".
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// ImagePullSecrets are the secrets to pull the image.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Resources are the compute resources of the Octopinger container.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// PriorityClassName is the priority class of the Octopinger pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Labels are additional labels of the Octopinger pods.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are additional annotations of the Octopinger pods.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// SecurityContext is the security context of the Octopinger container.
+	// The default is adding the NET_RAW capability, which is required by the ICMP probe.
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// HostNetwork is running the Octopinger pods in the network of the host.
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+
+	// ServiceAccountName is the service account of the Octopinger pods.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// UpdateStrategy is the strategy to replace the Octopinger pods.
+	UpdateStrategy appsv1.DaemonSetUpdateStrategy `json:"updateStrategy,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -110,6 +404,17 @@ const (
 	OctopingerPhaseFailed   OctopingerPhase = "Failed"
 )
 
+const (
+	// ConditionReady is true if the config is valid, the agents are available and the network is not degraded.
+	ConditionReady = "Ready"
+	// ConditionConfigValid is true if the config is valid.
+	ConditionConfigValid = "ConfigValid"
+	// ConditionDaemonSetAvailable is true if the agents are available on all desired nodes.
+	ConditionDaemonSetAvailable = "DaemonSetAvailable"
+	// ConditionDegraded is true if there are nodes with packet loss above the threshold.
+	ConditionDegraded = "Degraded"
+)
+
 // OctopingerStatus defines the observed state of Octopinger
 // +k8s:openapi-gen=true
 type OctopingerStatus struct {
@@ -119,6 +424,41 @@ type OctopingerStatus struct {
 	// ControlPaused indicates the operator pauses the control of
 	// Octopinger.
 	ControlPaused bool `json:"controlPaused,omitempty"`
+
+	// ObservedGeneration is the generation of the spec observed by the operator.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DesiredAgents is the number of nodes that should run an agent.
+	DesiredAgents int32 `json:"desiredAgents,omitempty"`
+
+	// ReadyAgents is the number of nodes that run a ready agent.
+	ReadyAgents int32 `json:"readyAgents,omitempty"`
+
+	// Connectivity is the summary of the latest results of the agents.
+	Connectivity *Connectivity `json:"connectivity,omitempty"`
+
+	// Conditions are the latest observations of the state of Octopinger.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Connectivity is the summary of the latest results of the agents.
+type Connectivity struct {
+	// Updated is the time the results were collected.
+	Updated metav1.Time `json:"updated"`
+
+	// ReportingAgents is the number of agents that reported results.
+	ReportingAgents int32 `json:"reportingAgents"`
+
+	// UnreachableLinks is the number of links between nodes on which all packets were lost.
+	UnreachableLinks int32 `json:"unreachableLinks"`
+
+	// DegradedNodes is the number of nodes with packet loss above the threshold.
+	DegradedNodes int32 `json:"degradedNodes"`
+
+	// DegradedNodeNames are the names of the nodes with packet loss above the threshold.
+	DegradedNodeNames []string `json:"degradedNodeNames,omitempty"`
 }
 
 // IsFailed ...
@@ -21,12 +21,16 @@ var (
 var build = fmt.Sprintf("%s (%s) (%s)", version, commit, date)
 
 type flags struct {
-	Debug      bool
-	ConfigPath string `env:"CONFIG_PATH" envDefault:"/etc/config"`
-	StatusAddr string `env:"STATUS_ADDR" envDefault:"0.0.0.0:8081"`
-	PodIP      string `env:"POD_IP"`
-	HostIP     string `env:"HOST_IP"`
-	Nodename   string `env:"NODE_NAME"`
+	Debug       bool
+	ConfigPath  string   `env:"CONFIG_PATH" envDefault:"/etc/config"`
+	StatusAddr  string   `env:"STATUS_ADDR" envDefault:"0.0.0.0:8081"`
+	PodIP       string   `env:"POD_IP"`
+	PodIPs      []string `env:"POD_IPS" envSeparator:","`
+	HostIP      string   `env:"HOST_IP"`
+	HostIPs     []string `env:"HOST_IPS" envSeparator:","`
+	Nodename    string   `env:"NODE_NAME"`
+	ServiceName string   `env:"SERVICE_NAME"`
+	ServiceIP   string   `env:"SERVICE_IP"`
 }
 
 var f = &flags{}
@@ -49,7 +53,11 @@ func init() {
 	rootCmd.Flags().StringVar(&f.StatusAddr, "status-addr", f.StatusAddr, "status addr")
 	rootCmd.Flags().StringVar(&f.Nodename, "nodename", f.Nodename, "node name")
 	rootCmd.Flags().StringVar(&f.PodIP, "pod-ip", f.PodIP, "pod ip")
+	rootCmd.Flags().StringSliceVar(&f.PodIPs, "pod-ips", f.PodIPs, "pod ips of all families")
 	rootCmd.Flags().StringVar(&f.HostIP, "host-ip", f.HostIP, "host ip")
+	rootCmd.Flags().StringSliceVar(&f.HostIPs, "host-ips", f.HostIPs, "host ips of all families")
+	rootCmd.Flags().StringVar(&f.ServiceName, "service-name", f.ServiceName, "service name")
+	rootCmd.Flags().StringVar(&f.ServiceIP, "service-ip", f.ServiceIP, "service ip")
 }
 
 func main() {
@@ -67,7 +75,7 @@ func runE(ctx context.Context) error {
 
 	defer func() { _ = logger.Sync() }()
 
-	logger.Sugar().Infow("starting octopinger", "build", build, "nodename", f.Nodename, "pod-ip", f.PodIP, "host-ip", f.HostIP)
+	logger.Sugar().Infow("starting octopinger", "build", build, "nodename", f.Nodename, "pod-ip", f.PodIP, "pod-ips", f.PodIPs, "host-ip", f.HostIP, "host-ips", f.HostIPs)
 
 	srv, _ := server.WithContext(ctx)
 
@@ -80,6 +88,8 @@ func runE(ctx context.Context) error {
 
 	api := octopinger.NewAPI(
 		octopinger.WithAddr(f.StatusAddr),
+		octopinger.WithResults(m.Results()),
+		octopinger.WithInstance(f.Nodename),
 	)
 	srv.Listen(api, false)
 
@@ -89,7 +99,11 @@ func runE(ctx context.Context) error {
 		octopinger.WithMonitor(m),
 		octopinger.WithNodeName(f.Nodename),
 		octopinger.WithPodIP(f.PodIP),
+		octopinger.WithPodIPs(f.PodIPs),
 		octopinger.WithHostIP(f.HostIP),
+		octopinger.WithHostIPs(f.HostIPs),
+		octopinger.WithServiceName(f.ServiceName),
+		octopinger.WithServiceIP(f.ServiceIP),
 	)
 	srv.Listen(o, false)
 
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	goruntime "runtime"
 
@@ -35,6 +36,10 @@ type flags struct {
 	enableLeaderElection bool
 	metricsAddr          string
 	probeAddr            string
+	matrixInterval       time.Duration
+	enableWebhooks       bool
+	webhookPort          int
+	webhookCertDir       string
 }
 
 var f = &flags{}
@@ -62,6 +67,10 @@ func init() {
 	rootCmd.Flags().BoolVar(&f.enableLeaderElection, "leader-elect", f.enableLeaderElection, "only one controller")
 	rootCmd.Flags().StringVar(&f.metricsAddr, "metrics-bind-address", ":8080", "metrics endpoint")
 	rootCmd.Flags().StringVar(&f.probeAddr, "health-probe-bind-address", ":8081", "health probe")
+	rootCmd.Flags().DurationVar(&f.matrixInterval, "matrix-interval", controller.DefaultMatrixInterval, "interval to collect the connectivity matrix")
+	rootCmd.Flags().BoolVar(&f.enableWebhooks, "enable-webhooks", f.enableWebhooks, "serve the defaulting and validating webhooks")
+	rootCmd.Flags().IntVar(&f.webhookPort, "webhook-port", webhook.DefaultPort, "webhook port")
+	rootCmd.Flags().StringVar(&f.webhookCertDir, "webhook-cert-dir", f.webhookCertDir, "directory of the webhook serving certificate")
 
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
@@ -89,7 +98,10 @@ func run(ctx context.Context) error {
 		Metrics: metricsserver.Options{
 			BindAddress: f.metricsAddr,
 		},
-		WebhookServer:              webhook.NewServer(webhook.Options{}),
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    f.webhookPort,
+			CertDir: f.webhookCertDir,
+		}),
 		HealthProbeBindAddress:     f.probeAddr,
 		LeaderElection:             f.enableLeaderElection,
 		LeaderElectionID:           "j8yhqdnj.octopinger.io",
@@ -135,5 +147,17 @@ func setupControllers(f *flags, mgr ctrl.Manager) error {
 		return err
 	}
 
+	err = controller.NewMatrixCollector(mgr, f.matrixInterval)
+	if err != nil {
+		return err
+	}
+
+	if f.enableWebhooks {
+		err = controller.NewOctopingerWebhook(mgr)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -2,14 +2,23 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 	"strings"
 
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
+
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -17,14 +26,16 @@ import (
 
 // NewConfigReconciler ...
 func NewConfigReconciler(mgr manager.Manager) error {
+	r := &configReconciler{
+		Client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&appsv1.DaemonSet{}).
-		Owns(&corev1.Pod{}).
-		WithEventFilter(OcotopingerManaged()).
-		Complete(&configReconciler{
-			Client: mgr.GetClient(),
-			scheme: mgr.GetScheme(),
-		})
+		For(&appsv1.DaemonSet{}, builder.WithPredicates(OcotopingerManaged())).
+		Owns(&corev1.Pod{}, builder.WithPredicates(OcotopingerManaged())).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(r.daemonSetsOfEndpointSlice), builder.WithPredicates(hasServiceName())).
+		Complete(r)
 }
 
 type configReconciler struct {
@@ -57,25 +68,65 @@ func (s *configReconciler) Reconcile(ctx context.Context, r reconcile.Request) (
 		return reconcile.Result{}, err
 	}
 
-	cfg := &corev1.ConfigMap{}
-	err = s.Get(ctx, types.NamespacedName{Namespace: ds.Namespace, Name: strings.TrimSuffix(ds.Name, "-daemonset") + "-config"}, cfg)
+	cfg, err := configMapOf(ctx, s, ds)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	log.Info("fetching list of pods")
 
-	pods := &corev1.PodList{}
-	err = s.List(ctx, pods, client.InNamespace(r.Namespace), client.MatchingLabels(ds.Spec.Template.Labels))
+	pods, err := listAgents(ctx, s, ds)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	ips := []string{}
+	selected, err := selectedNodes(ctx, s, ds)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	nodes := make([]octopinger.Node, 0, len(pods.Items))
 	for _, p := range pods.Items {
-		ips = append(ips, p.Status.HostIP)
+		if p.Status.HostIP == "" || p.Spec.NodeName == "" {
+			continue
+		}
+
+		// pods on nodes that are no longer selected are terminated by the DaemonSet
+		node, ok := selected[p.Spec.NodeName]
+		if p.DeletionTimestamp != nil || !ok {
+			continue
+		}
+
+		nodes = append(nodes, newNode(node, &p))
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	bb, err := json.Marshal(nodes)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	cfg.Data["nodes"] = string(bb)
+
+	spec, err := specOf(cfg)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if spec.DNS.Enable && spec.DNS.Endpoints {
+		endpoints, err := dnsEndpoints(ctx, s, spec.DNS.Service)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+
+		bb, err := json.Marshal(endpoints)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		cfg.Data["dns_endpoints"] = string(bb)
+	} else {
+		delete(cfg.Data, "dns_endpoints")
 	}
-	cfg.Data["nodes"] = strings.Join(ips, "\n")
 
 	log.Info("updating list of pods")
 
@@ -86,3 +137,197 @@ func (s *configReconciler) Reconcile(ctx context.Context, r reconcile.Request) (
 
 	return reconcile.Result{}, nil
 }
+
+// daemonSetsOfEndpointSlice returns the DaemonSets that probe the endpoints of the Service of the slice.
+func (s *configReconciler) daemonSetsOfEndpointSlice(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &appsv1.DaemonSetList{}
+	if err := s.List(ctx, list); err != nil {
+		return nil
+	}
+
+	managed := OcotopingerManaged()
+	requests := make([]reconcile.Request, 0)
+
+	for i := range list.Items {
+		ds := &list.Items[i]
+		if !managed.Generic(event.GenericEvent{Object: ds}) {
+			continue
+		}
+
+		cfg, err := configMapOf(ctx, s, ds)
+		if err != nil {
+			continue
+		}
+
+		spec, err := specOf(cfg)
+		if err != nil || !spec.DNS.Enable || !spec.DNS.Endpoints {
+			continue
+		}
+
+		namespace, name, err := octopinger.DNSService(spec.DNS.Service)
+		if err != nil || namespace != obj.GetNamespace() || name != obj.GetLabels()[discoveryv1.LabelServiceName] {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ds)})
+	}
+
+	return requests
+}
+
+func hasServiceName() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetLabels()[discoveryv1.LabelServiceName] != ""
+	})
+}
+
+func configMapOf(ctx context.Context, c client.Client, ds *appsv1.DaemonSet) (*corev1.ConfigMap, error) {
+	cfg := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: ds.Namespace, Name: strings.TrimSuffix(ds.Name, "-daemonset") + "-config"}, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// specOf returns the config of the probes in the config map.
+func specOf(cfg *corev1.ConfigMap) (*v1alpha1.Config, error) {
+	spec := &v1alpha1.Config{}
+	if cfg.Data["config"] == "" {
+		return spec, nil
+	}
+
+	err := json.Unmarshal([]byte(cfg.Data["config"]), spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// dnsEndpoints returns the endpoints of the cluster DNS Service.
+func dnsEndpoints(ctx context.Context, c client.Client, service string) ([]octopinger.DNSEndpoint, error) {
+	namespace, name, err := octopinger.DNSService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	slices := &discoveryv1.EndpointSliceList{}
+	err = c.List(ctx, slices, client.InNamespace(namespace), client.MatchingLabels{discoveryv1.LabelServiceName: name})
+	if err != nil {
+		return nil, err
+	}
+
+	return newDNSEndpoints(slices.Items), nil
+}
+
+// newDNSEndpoints returns the entries of the "dns_endpoints" file.
+// The endpoints of the IP families are in separate slices, which are merged by the pod.
+func newDNSEndpoints(slices []discoveryv1.EndpointSlice) []octopinger.DNSEndpoint {
+	index := make(map[string]int)
+	endpoints := make([]octopinger.DNSEndpoint, 0)
+
+	for _, slice := range slices {
+		port := dnsPort(slice.Ports)
+
+		for _, e := range slice.Endpoints {
+			if len(e.Addresses) == 0 || (e.Conditions.Terminating != nil && *e.Conditions.Terminating) {
+				continue
+			}
+
+			name := e.Addresses[0]
+			if e.TargetRef != nil {
+				name = e.TargetRef.Name
+			}
+
+			i, ok := index[name]
+			if !ok {
+				i = len(endpoints)
+				index[name] = i
+				endpoints = append(endpoints, octopinger.DNSEndpoint{Name: name, Port: port})
+			}
+
+			if e.NodeName != nil {
+				endpoints[i].Node = *e.NodeName
+			}
+			endpoints[i].IPs = append(endpoints[i].IPs, e.Addresses...)
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Name < endpoints[j].Name })
+
+	return endpoints
+}
+
+// dnsPort returns the UDP port of the DNS Service, which is named "dns" by kube-dns and CoreDNS.
+func dnsPort(ports []discoveryv1.EndpointPort) int {
+	port := 0
+
+	for _, p := range ports {
+		if p.Port == nil || (p.Protocol != nil && *p.Protocol != corev1.ProtocolUDP) {
+			continue
+		}
+
+		if p.Name != nil && *p.Name == "dns" {
+			return int(*p.Port)
+		}
+
+		if port == 0 {
+			port = int(*p.Port)
+		}
+	}
+
+	return port
+}
+
+func listAgents(ctx context.Context, c client.Client, ds *appsv1.DaemonSet) (*corev1.PodList, error) {
+	pods := &corev1.PodList{}
+	err := c.List(ctx, pods, client.InNamespace(ds.Namespace), client.MatchingLabels(ds.Spec.Template.Labels))
+	if err != nil {
+		return nil, err
+	}
+
+	return pods, nil
+}
+
+// selectedNodes returns the nodes selected by the node selector of the DaemonSet by name.
+func selectedNodes(ctx context.Context, c client.Client, ds *appsv1.DaemonSet) (map[string]*corev1.Node, error) {
+	nodes := &corev1.NodeList{}
+	err := c.List(ctx, nodes, client.MatchingLabels(ds.Spec.Template.Spec.NodeSelector))
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]*corev1.Node, len(nodes.Items))
+	for i := range nodes.Items {
+		selected[nodes.Items[i].Name] = &nodes.Items[i]
+	}
+
+	return selected, nil
+}
+
+// newNode returns the entry of the "nodes" file for the pod of an agent.
+func newNode(node *corev1.Node, pod *corev1.Pod) octopinger.Node {
+	n := octopinger.Node{
+		Name:   node.Name,
+		Zone:   node.Labels[corev1.LabelTopologyZone],
+		Region: node.Labels[corev1.LabelTopologyRegion],
+	}
+
+	for _, ip := range pod.Status.HostIPs {
+		n.HostIPs = append(n.HostIPs, ip.IP)
+	}
+	if len(n.HostIPs) == 0 {
+		n.HostIPs = []string{pod.Status.HostIP}
+	}
+
+	for _, ip := range pod.Status.PodIPs {
+		n.PodIPs = append(n.PodIPs, ip.IP)
+	}
+	if len(n.PodIPs) == 0 && pod.Status.PodIP != "" {
+		n.PodIPs = []string{pod.Status.PodIP}
+	}
+
+	return n
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Nodes", func() {
+	Context("When listing the nodes of the agents", func() {
+		It("Should add the IPs and the topology of the node", func() {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node-a",
+					Labels: map[string]string{
+						corev1.LabelTopologyZone:   "de-fra-1",
+						corev1.LabelTopologyRegion: "de-fra",
+					},
+				},
+			}
+
+			pod := &corev1.Pod{
+				Status: corev1.PodStatus{
+					HostIP:  "10.0.0.1",
+					HostIPs: []corev1.HostIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+					PodIP:   "10.244.0.1",
+				},
+			}
+
+			Expect(newNode(node, pod)).Should(Equal(octopinger.Node{
+				Name:    "node-a",
+				HostIPs: []string{"10.0.0.1", "fd00::1"},
+				PodIPs:  []string{"10.244.0.1"},
+				Zone:    "de-fra-1",
+				Region:  "de-fra",
+			}))
+		})
+	})
+})
+
+var _ = Describe("DNS endpoints", func() {
+	Context("When listing the endpoints of the cluster DNS Service", func() {
+		It("Should merge the slices of the IP families by pod", func() {
+			dns, udp, tcp := "dns", corev1.ProtocolUDP, corev1.ProtocolTCP
+			metrics, port, metricsPort := "metrics", int32(53), int32(9153)
+			nodeA, terminating := "node-a", true
+
+			ports := []discoveryv1.EndpointPort{
+				{Name: &metrics, Protocol: &tcp, Port: &metricsPort},
+				{Name: &dns, Protocol: &udp, Port: &port},
+			}
+
+			slices := []discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Ports:       ports,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"10.244.0.2"}, NodeName: &nodeA, TargetRef: &corev1.ObjectReference{Name: "coredns-a"}},
+						{Addresses: []string{"10.244.1.2"}, TargetRef: &corev1.ObjectReference{Name: "coredns-b"}, Conditions: discoveryv1.EndpointConditions{Terminating: &terminating}},
+					},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv6,
+					Ports:       ports,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"fd00:10:244::2"}, NodeName: &nodeA, TargetRef: &corev1.ObjectReference{Name: "coredns-a"}},
+					},
+				},
+			}
+
+			Expect(newDNSEndpoints(slices)).Should(Equal([]octopinger.DNSEndpoint{
+				{Name: "coredns-a", Node: "node-a", IPs: []string{"10.244.0.2", "fd00:10:244::2"}, Port: 53},
+			}))
+		})
+	})
+})
@@ -2,26 +2,47 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"reflect"
+	"path"
+	"slices"
 
 	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
 	"github.com/ionos-cloud/octopinger/pkg/utils"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+const (
+	// NodeLabel is the label of the nodes that should run Octopinger.
+	NodeLabel = "octopinger"
+	// ConfigHashAnnotation is the annotation of the pod template with the hash of the config,
+	// so that changes of the config are rolled out to the DaemonSet.
+	ConfigHashAnnotation = "octopinger.io/config-hash"
+	// SpecHashAnnotation is the annotation of the DaemonSet with the hash of its desired spec,
+	// so that the DaemonSet is only updated if the desired spec changes.
+	SpecHashAnnotation = "octopinger.io/spec-hash"
+
+	// statusServerPort is the port of the status server of the instances.
+	statusServerPort = octopinger.StatusPort
+)
+
 // NewConfigMapData ..
 func NewConfigMapData() ConfigMapData {
 	return ConfigMapData{"nodes": "", "config": "{}"}
@@ -42,15 +63,252 @@ func (c ConfigMapData) SetConfig(cfg *v1alpha1.Config) error {
 	return nil
 }
 
+// Hash returns the hash of the config.
+func (c ConfigMapData) Hash() string {
+	sum := sha256.Sum256([]byte(c["config"]))
+
+	return hex.EncodeToString(sum[:])
+}
+
 // SetNodes ...
 func (c *ConfigMapData) SetNodes() error {
 	return nil
 }
 
+// specHash returns the hash of the desired spec of a DaemonSet.
+func specHash(spec *appsv1.DaemonSetSpec) (string, error) {
+	bb, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bb)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// nodeSelector returns the selector of the nodes to run Octopinger on.
+func nodeSelector(octopinger *v1alpha1.Octopinger) map[string]string {
+	if octopinger.Spec.Label == "" && len(octopinger.Spec.Template.NodeSelector) == 0 {
+		return nil
+	}
+
+	selector := make(map[string]string, len(octopinger.Spec.Template.NodeSelector)+1)
+	for k, v := range octopinger.Spec.Template.NodeSelector {
+		selector[k] = v
+	}
+
+	if octopinger.Spec.Label != "" {
+		selector[NodeLabel] = octopinger.Spec.Label
+	}
+
+	return selector
+}
+
+// podLabels returns the labels of the pod template.
+// The labels of the selector cannot be overridden.
+func podLabels(octopinger *v1alpha1.Octopinger) map[string]string {
+	labels := make(map[string]string, len(octopinger.Spec.Template.Labels)+2)
+	for k, v := range octopinger.Spec.Template.Labels {
+		labels[k] = v
+	}
+
+	labels["daemonset"] = octopinger.Name + "-daemonset"
+	labels["octopinger"] = octopinger.Name
+
+	return labels
+}
+
+// podAnnotations returns the annotations of the pod template.
+func podAnnotations(octopinger *v1alpha1.Octopinger, hash string) map[string]string {
+	annotations := make(map[string]string, len(octopinger.Spec.Template.Annotations)+1)
+	for k, v := range octopinger.Spec.Template.Annotations {
+		annotations[k] = v
+	}
+
+	annotations[ConfigHashAnnotation] = hash
+
+	return annotations
+}
+
+// imagePullPolicy returns the pull policy of the image, which defaults to always.
+func imagePullPolicy(octopinger *v1alpha1.Octopinger) corev1.PullPolicy {
+	if octopinger.Spec.Template.ImagePullPolicy == "" {
+		return corev1.PullAlways
+	}
+
+	return octopinger.Spec.Template.ImagePullPolicy
+}
+
+// securityContext returns the security context of the container.
+// The ICMP probe requires raw sockets, unless it is using datagram ping sockets.
+func securityContext(octopinger *v1alpha1.Octopinger) *corev1.SecurityContext {
+	if octopinger.Spec.Template.SecurityContext != nil {
+		return octopinger.Spec.Template.SecurityContext
+	}
+
+	if octopinger.Spec.Config.ICMP.Socket == v1alpha1.ICMPSocketDatagram {
+		return &corev1.SecurityContext{
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		}
+	}
+
+	return &corev1.SecurityContext{
+		Capabilities: &corev1.Capabilities{
+			Add: []corev1.Capability{"NET_RAW"},
+		},
+	}
+}
+
+// podSecurityContext returns the security context of the pod, which allows datagram ping sockets
+// for all groups in the network namespace of the pod. The network namespace of the host
+// is configured by the node.
+func podSecurityContext(octopinger *v1alpha1.Octopinger) *corev1.PodSecurityContext {
+	if octopinger.Spec.Config.ICMP.Socket != v1alpha1.ICMPSocketDatagram || octopinger.Spec.Template.HostNetwork {
+		return nil
+	}
+
+	return &corev1.PodSecurityContext{
+		Sysctls: []corev1.Sysctl{
+			{Name: "net.ipv4.ping_group_range", Value: "0 2147483647"},
+		},
+	}
+}
+
+// dnsPolicy returns the DNS policy of the pod, which has to resolve
+// the cluster names in the network of the host, too.
+func dnsPolicy(octopinger *v1alpha1.Octopinger) corev1.DNSPolicy {
+	if octopinger.Spec.Template.HostNetwork {
+		return corev1.DNSClusterFirstWithHostNet
+	}
+
+	return corev1.DNSClusterFirst
+}
+
+// nodeResolvConf returns the volumes and the mounts of the resolv.conf of the node,
+// if the search path probe is reading it. The runtime directory of systemd-resolved
+// is mounted, too, as its resolv.conf is preferred on nodes with systemd-resolved.
+func nodeResolvConf(cfg *v1alpha1.Config) ([]corev1.Volume, []corev1.VolumeMount) {
+	if cfg.DNS.SearchPath.ResolvConf != v1alpha1.DNSResolvConfNode {
+		return nil, nil
+	}
+
+	fileType := corev1.HostPathFile
+	dirType := corev1.HostPathDirectoryOrCreate
+
+	volumes := []corev1.Volume{
+		{
+			Name: "node-resolv-conf",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: octopinger.NodeResolvConfHostPath,
+					Type: &fileType,
+				},
+			},
+		},
+		{
+			Name: "node-resolved",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: octopinger.NodeResolvedDirHostPath,
+					Type: &dirType,
+				},
+			},
+		},
+	}
+
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      "node-resolv-conf",
+			MountPath: octopinger.NodeResolvConf,
+			ReadOnly:  true,
+		},
+		{
+			Name:      "node-resolved",
+			MountPath: octopinger.NodeResolvedDir,
+			ReadOnly:  true,
+		},
+	}
+
+	return volumes, mounts
+}
+
+// dnsCA returns the volume and the mount of the ConfigMap with the certificate authorities
+// of the encrypted name servers, if the DNS probes are verifying them.
+func dnsCA(cfg *v1alpha1.Config) ([]corev1.Volume, []corev1.VolumeMount) {
+	ca := cfg.DNS.TLS.CAConfigMap
+	if ca == nil {
+		return nil, nil
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name: "dns-ca",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: ca.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: ca.Key, Path: octopinger.DNSCAFile}},
+					Optional:             ca.Optional,
+				},
+			},
+		},
+	}
+
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      "dns-ca",
+			MountPath: octopinger.DNSCADir,
+			ReadOnly:  true,
+		},
+	}
+
+	return volumes, mounts
+}
+
+// tlsCA returns the volumes and the mounts of the ConfigMaps with the certificate authorities
+// of the TLS targets. Each ConfigMap is mounted once into a directory of its name.
+func tlsCA(cfg *v1alpha1.Config) ([]corev1.Volume, []corev1.VolumeMount) {
+	names := []string{}
+	for _, target := range cfg.TLS.Targets {
+		if target.CAConfigMap != nil && !slices.Contains(names, target.CAConfigMap.Name) {
+			names = append(names, target.CAConfigMap.Name)
+		}
+	}
+	slices.Sort(names)
+
+	volumes := make([]corev1.Volume, 0, len(names))
+	mounts := make([]corev1.VolumeMount, 0, len(names))
+
+	for i, name := range names {
+		volumeName := fmt.Sprintf("tls-ca-%d", i)
+
+		volumes = append(volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				},
+			},
+		})
+
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: path.Join(octopinger.TLSCADir, name),
+			ReadOnly:  true,
+		})
+	}
+
+	return volumes, mounts
+}
+
 // NewDaemonReconciler ...
 func NewDaemonReconciler(mgr manager.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.Octopinger{}).
+		For(&v1alpha1.Octopinger{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, degradedChanged()))).
+		Owns(&appsv1.DaemonSet{}).
+		Owns(&corev1.Service{}).
 		Complete(&daemonReconciler{
 			Client: mgr.GetClient(),
 			scheme: mgr.GetScheme(),
@@ -94,9 +352,12 @@ func (d *daemonReconciler) Reconcile(ctx context.Context, r reconcile.Request) (
 	return reconcile.Result{}, nil
 }
 
-func (d *daemonReconciler) reconcileStatus(ctx context.Context, octopinger *v1alpha1.Octopinger) error {
+func (d *daemonReconciler) reconcileStatus(ctx context.Context, octopinger *v1alpha1.Octopinger, configErr error) error {
+	status := octopinger.Status.DeepCopy()
 	phase := v1alpha1.OctopingerPhaseNone
 
+	var found *appsv1.DaemonSet
+
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      octopinger.Name + "-daemonset",
@@ -105,19 +366,29 @@ func (d *daemonReconciler) reconcileStatus(ctx context.Context, octopinger *v1al
 	}
 	if utils.IsObjectFound(ctx, d, octopinger.Namespace, ds.Name, ds) {
 		phase = v1alpha1.OctopingerPhaseCreating
+		found = ds
 
 		if ds.Status.CurrentNumberScheduled == ds.Status.DesiredNumberScheduled {
 			phase = v1alpha1.OctopingerPhaseRunning
 		}
 	}
 
-	if octopinger.Status.Phase != phase {
-		octopinger.Status.Phase = phase
+	if configErr != nil {
+		phase = v1alpha1.OctopingerPhaseFailed
+	}
+
+	octopinger.Status.Phase = phase
+	octopinger.Status.ObservedGeneration = octopinger.Generation
+
+	setConfigCondition(octopinger, configErr)
+	setDaemonSetCondition(octopinger, found)
+	setReadyCondition(octopinger)
 
-		return d.Status().Update(ctx, octopinger)
+	if equality.Semantic.DeepEqual(status, &octopinger.Status) {
+		return nil
 	}
 
-	return nil
+	return d.Status().Update(ctx, octopinger)
 }
 
 func (d *daemonReconciler) reconcileDaemonSets(ctx context.Context, octopinger *v1alpha1.Octopinger) error {
@@ -130,11 +401,24 @@ func (d *daemonReconciler) reconcileDaemonSets(ctx context.Context, octopinger *
 		return err
 	}
 
-	items := []corev1.KeyToPath{}
-	for k := range configMap.Data {
-		items = append(items, corev1.KeyToPath{Key: k, Path: k})
+	env := []corev1.EnvVar{}
+	if octopinger.Spec.Config.Service.Enable {
+		svc := &corev1.Service{}
+		err = utils.FetchObject(ctx, d, octopinger.Namespace, serviceName(octopinger), svc)
+		if err != nil {
+			return err
+		}
+
+		env = append(env,
+			corev1.EnvVar{Name: "SERVICE_IP", Value: svc.Spec.ClusterIP},
+			corev1.EnvVar{Name: "SERVICE_NAME", Value: fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)},
+		)
 	}
 
+	resolvConfVolumes, resolvConfMounts := nodeResolvConf(&octopinger.Spec.Config)
+	dnsCAVolumes, dnsCAMounts := dnsCA(&octopinger.Spec.Config)
+	tlsCAVolumes, tlsCAMounts := tlsCA(&octopinger.Spec.Config)
+
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      octopinger.Name + "-daemonset",
@@ -147,29 +431,30 @@ func (d *daemonReconciler) reconcileDaemonSets(ctx context.Context, octopinger *
 					"octopinger": octopinger.Name,
 				},
 			},
+			UpdateStrategy: octopinger.Spec.Template.UpdateStrategy,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"daemonset":  octopinger.Name + "-daemonset",
-						"octopinger": octopinger.Name,
-					},
+					Labels:      podLabels(octopinger),
+					Annotations: podAnnotations(octopinger, ConfigMapData(configMap.Data).Hash()),
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
 							Name:            "octopinger-container",
-							ImagePullPolicy: corev1.PullAlways,
+							ImagePullPolicy: imagePullPolicy(octopinger),
 							Image:           octopinger.Spec.Template.Image,
-							VolumeMounts: []corev1.VolumeMount{
+							Resources:       octopinger.Spec.Template.Resources,
+							SecurityContext: securityContext(octopinger),
+							VolumeMounts: append([]corev1.VolumeMount{
 								{
 									Name:      "config-vol",
 									MountPath: "/etc/config",
 								},
-							},
+							}, slices.Concat(resolvConfMounts, dnsCAMounts, tlsCAMounts)...),
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "status",
-									ContainerPort: 8081,
+									ContainerPort: statusServerPort,
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
@@ -181,7 +466,7 @@ func (d *daemonReconciler) reconcileDaemonSets(ctx context.Context, octopinger *
 									},
 								},
 							},
-							Env: []corev1.EnvVar{
+							Env: append([]corev1.EnvVar{
 								{
 									Name: "NODE_NAME",
 									ValueFrom: &corev1.EnvVarSource{
@@ -198,6 +483,14 @@ func (d *daemonReconciler) reconcileDaemonSets(ctx context.Context, octopinger *
 										},
 									},
 								},
+								{
+									Name: "POD_IPS",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "status.podIPs",
+										},
+									},
+								},
 								{
 									Name: "HOST_IP",
 									ValueFrom: &corev1.EnvVarSource{
@@ -206,28 +499,51 @@ func (d *daemonReconciler) reconcileDaemonSets(ctx context.Context, octopinger *
 										},
 									},
 								},
-							},
+								{
+									Name: "HOST_IPS",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "status.hostIPs",
+										},
+									},
+								},
+							}, env...),
 						},
 					},
-					Tolerations: octopinger.Spec.Template.Tolerations,
-					Volumes: []corev1.Volume{
+					Tolerations:        octopinger.Spec.Template.Tolerations,
+					NodeSelector:       nodeSelector(octopinger),
+					Affinity:           octopinger.Spec.Template.Affinity,
+					ImagePullSecrets:   octopinger.Spec.Template.ImagePullSecrets,
+					PriorityClassName:  octopinger.Spec.Template.PriorityClassName,
+					ServiceAccountName: octopinger.Spec.Template.ServiceAccountName,
+					HostNetwork:        octopinger.Spec.Template.HostNetwork,
+					DNSPolicy:          dnsPolicy(octopinger),
+					SecurityContext:    podSecurityContext(octopinger),
+					Volumes: append([]corev1.Volume{
 						{
 							Name: "config-vol",
 							VolumeSource: corev1.VolumeSource{
 								ConfigMap: &corev1.ConfigMapVolumeSource{
+									// all keys are mounted, so that keys written later by the config reconciler,
+									// e.g. the DNS endpoints, are not missing until the next rollout
 									LocalObjectReference: corev1.LocalObjectReference{
 										Name: octopinger.Name + "-config",
 									},
-									Items: items,
 								},
 							},
 						},
-					},
+					}, slices.Concat(resolvConfVolumes, dnsCAVolumes, tlsCAVolumes)...),
 				},
 			},
 		},
 	}
 
+	hash, err := specHash(&ds.Spec)
+	if err != nil {
+		return err
+	}
+	ds.Annotations = map[string]string{SpecHashAnnotation: hash}
+
 	err = controllerutil.SetControllerReference(octopinger, ds, d.scheme)
 	if err != nil {
 		return err
@@ -242,12 +558,20 @@ func (d *daemonReconciler) reconcileDaemonSets(ctx context.Context, octopinger *
 			return nil
 		}
 
-		if !reflect.DeepEqual(existingDS, ds) {
-			existingDS = ds
-			return d.Update(ctx, existingDS)
+		// the live DaemonSet carries defaulted fields, so the desired spec is compared by its hash
+		if existingDS.Annotations[SpecHashAnnotation] == hash {
+			return nil
 		}
 
-		return nil
+		log.Info(fmt.Sprintf("updating %s", octopinger.Name+"-daemonset"))
+
+		if existingDS.Annotations == nil {
+			existingDS.Annotations = map[string]string{}
+		}
+		existingDS.Annotations[SpecHashAnnotation] = hash
+		existingDS.Spec = ds.Spec
+
+		return d.Update(ctx, existingDS)
 	}
 
 	log.Info(fmt.Sprintf("creating %s", octopinger.Name+"-daemonset"))
@@ -260,17 +584,29 @@ func (d *daemonReconciler) reconcileConfigMaps(ctx context.Context, octopinger *
 
 	log.Info("reconciling config map")
 
-	configMap := &corev1.ConfigMap{}
-	if utils.IsObjectFound(ctx, d, octopinger.Namespace, octopinger.Name+"-config", configMap) {
-		return nil
-	}
-
 	configMapData := NewConfigMapData()
 	err := configMapData.SetConfig(&octopinger.Spec.Config)
 	if err != nil {
 		return err
 	}
 
+	configMap := &corev1.ConfigMap{}
+	if utils.IsObjectFound(ctx, d, octopinger.Namespace, octopinger.Name+"-config", configMap) {
+		if configMap.Data["config"] == configMapData["config"] {
+			return nil
+		}
+
+		// the nodes are owned by the config reconciler
+		if configMap.Data == nil {
+			configMap.Data = NewConfigMapData()
+		}
+		configMap.Data["config"] = configMapData["config"]
+
+		log.Info("updating config map")
+
+		return d.Update(ctx, configMap)
+	}
+
 	configMap = &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      octopinger.Name + "-config",
@@ -287,17 +623,157 @@ func (d *daemonReconciler) reconcileConfigMaps(ctx context.Context, octopinger *
 	return d.Create(ctx, configMap)
 }
 
+// serviceName returns the name of the ClusterIP Service of the instances.
+func serviceName(octopinger *v1alpha1.Octopinger) string {
+	return octopinger.Name + "-service"
+}
+
+// headlessServiceName returns the name of the headless Service of the instances.
+func headlessServiceName(octopinger *v1alpha1.Octopinger) string {
+	return octopinger.Name + "-headless"
+}
+
+// services returns the ClusterIP and the headless Service in front of the status port of the instances.
+// The headless Service resolves to the pod IPs of the ready instances.
+func services(octopinger *v1alpha1.Octopinger) []*corev1.Service {
+	spec := corev1.ServiceSpec{
+		Type: corev1.ServiceTypeClusterIP,
+		Selector: map[string]string{
+			"daemonset":  octopinger.Name + "-daemonset",
+			"octopinger": octopinger.Name,
+		},
+		Ports: []corev1.ServicePort{
+			{
+				Name:       "status",
+				Port:       statusServerPort,
+				TargetPort: intstr.FromString("status"),
+				Protocol:   corev1.ProtocolTCP,
+			},
+		},
+	}
+
+	headless := spec.DeepCopy()
+	headless.ClusterIP = corev1.ClusterIPNone
+
+	return []*corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      serviceName(octopinger),
+				Namespace: octopinger.Namespace,
+			},
+			Spec: spec,
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      headlessServiceName(octopinger),
+				Namespace: octopinger.Namespace,
+			},
+			Spec: *headless,
+		},
+	}
+}
+
+func (d *daemonReconciler) reconcileServices(ctx context.Context, octopinger *v1alpha1.Octopinger) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	log.Info("reconciling services")
+
+	for _, svc := range services(octopinger) {
+		existing := &corev1.Service{}
+		if utils.IsObjectFound(ctx, d, octopinger.Namespace, svc.Name, existing) {
+			// this Service is not owned by Octopinger
+			if ownerRef := metav1.GetControllerOf(existing); ownerRef == nil || ownerRef.Kind != v1alpha1.CRDResourceKind {
+				continue
+			}
+
+			// the cluster IP is allocated by the API server
+			if equality.Semantic.DeepDerivative(svc.Spec, existing.Spec) {
+				continue
+			}
+
+			log.Info(fmt.Sprintf("updating %s", svc.Name))
+
+			existing.Spec.Type = svc.Spec.Type
+			existing.Spec.Selector = svc.Spec.Selector
+			existing.Spec.Ports = svc.Spec.Ports
+
+			err := d.Update(ctx, existing)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := controllerutil.SetControllerReference(octopinger, svc, d.scheme)
+		if err != nil {
+			return err
+		}
+
+		log.Info(fmt.Sprintf("creating %s", svc.Name))
+
+		err = d.Create(ctx, svc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteServices deletes the Services of the instances, when the Service probe is turned off.
+func (d *daemonReconciler) deleteServices(ctx context.Context, octopinger *v1alpha1.Octopinger) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	for _, svc := range services(octopinger) {
+		existing := &corev1.Service{}
+		if !utils.IsObjectFound(ctx, d, octopinger.Namespace, svc.Name, existing) {
+			continue
+		}
+
+		// this Service is not owned by Octopinger
+		if ownerRef := metav1.GetControllerOf(existing); ownerRef == nil || ownerRef.Kind != v1alpha1.CRDResourceKind {
+			continue
+		}
+
+		log.Info(fmt.Sprintf("deleting %s", svc.Name))
+
+		err := d.Delete(ctx, existing)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *daemonReconciler) reconcileResources(ctx context.Context, octopinger *v1alpha1.Octopinger) error {
-	err := d.reconcileStatus(ctx, octopinger)
+	configErr := validateConfig(&octopinger.Spec.Config)
+
+	err := d.reconcileStatus(ctx, octopinger, configErr)
 	if err != nil {
 		return err
 	}
 
+	// an invalid config is not rolled out to the agents
+	if configErr != nil {
+		return nil
+	}
+
 	err = d.reconcileConfigMaps(ctx, octopinger)
 	if err != nil {
 		return err
 	}
 
+	if octopinger.Spec.Config.Service.Enable {
+		err = d.reconcileServices(ctx, octopinger)
+	} else {
+		err = d.deleteServices(ctx, octopinger)
+	}
+	if err != nil {
+		return err
+	}
+
 	err = d.reconcileDaemonSets(ctx, octopinger)
 	if err != nil {
 		return err
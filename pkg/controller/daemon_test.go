@@ -2,14 +2,22 @@ package controller
 
 import (
 	"context"
+	"testing"
 	"time"
 
 	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 // +kubebuilder:docs-gen:collapse=Imports
@@ -66,3 +74,213 @@ var _ = Describe("DaemonSet controller", func() {
 		})
 	})
 })
+
+var _ = Describe("ConfigMapData", func() {
+	Context("When hashing the config", func() {
+		It("Should only change with the config", func() {
+			data := NewConfigMapData()
+			Expect(data.SetConfig(&v1alpha1.Config{ICMP: v1alpha1.ICMP{Enable: true}})).Should(Succeed())
+
+			hash := data.Hash()
+
+			data["nodes"] = "10.0.0.1 node-a host"
+			Expect(data.Hash()).Should(Equal(hash))
+
+			Expect(data.SetConfig(&v1alpha1.Config{ICMP: v1alpha1.ICMP{Enable: false}})).Should(Succeed())
+			Expect(data.Hash()).ShouldNot(Equal(hash))
+		})
+	})
+})
+
+var _ = Describe("Node selector", func() {
+	Context("When selecting the nodes", func() {
+		It("Should merge the label into the node selector", func() {
+			o := &v1alpha1.Octopinger{}
+			Expect(nodeSelector(o)).Should(BeNil())
+
+			o.Spec.Label = "octopinger"
+			o.Spec.Template.NodeSelector = map[string]string{"node.kubernetes.io/pool": "default"}
+			Expect(nodeSelector(o)).Should(Equal(map[string]string{
+				NodeLabel:                 "octopinger",
+				"node.kubernetes.io/pool": "default",
+			}))
+		})
+	})
+})
+
+var _ = Describe("Pod template", func() {
+	Context("When building the pod template", func() {
+		It("Should keep the labels of the selector", func() {
+			o := &v1alpha1.Octopinger{}
+			o.Name = "demo"
+			o.Spec.Template.Labels = map[string]string{"octopinger": "other", "team": "network"}
+
+			Expect(podLabels(o)).Should(Equal(map[string]string{
+				"daemonset":  "demo-daemonset",
+				"octopinger": "demo",
+				"team":       "network",
+			}))
+		})
+
+		It("Should keep the config hash annotation", func() {
+			o := &v1alpha1.Octopinger{}
+			o.Spec.Template.Annotations = map[string]string{ConfigHashAnnotation: "other", "team": "network"}
+
+			Expect(podAnnotations(o, "hash")).Should(Equal(map[string]string{
+				ConfigHashAnnotation: "hash",
+				"team":               "network",
+			}))
+		})
+
+		It("Should default the pull policy and the security context", func() {
+			o := &v1alpha1.Octopinger{}
+
+			Expect(imagePullPolicy(o)).Should(Equal(corev1.PullAlways))
+			Expect(securityContext(o).Capabilities.Add).Should(ContainElement(corev1.Capability("NET_RAW")))
+			Expect(dnsPolicy(o)).Should(Equal(corev1.DNSClusterFirst))
+
+			o.Spec.Template.ImagePullPolicy = corev1.PullIfNotPresent
+			o.Spec.Template.SecurityContext = &corev1.SecurityContext{}
+			o.Spec.Template.HostNetwork = true
+
+			Expect(imagePullPolicy(o)).Should(Equal(corev1.PullIfNotPresent))
+			Expect(securityContext(o)).Should(Equal(&corev1.SecurityContext{}))
+			Expect(dnsPolicy(o)).Should(Equal(corev1.DNSClusterFirstWithHostNet))
+		})
+
+		It("Should allow datagram ping sockets without NET_RAW", func() {
+			o := &v1alpha1.Octopinger{}
+
+			Expect(podSecurityContext(o)).Should(BeNil())
+
+			o.Spec.Config.ICMP.Socket = v1alpha1.ICMPSocketDatagram
+
+			Expect(securityContext(o).Capabilities.Add).Should(BeEmpty())
+			Expect(securityContext(o).Capabilities.Drop).Should(ContainElement(corev1.Capability("ALL")))
+			Expect(podSecurityContext(o).Sysctls).Should(Equal([]corev1.Sysctl{{Name: "net.ipv4.ping_group_range", Value: "0 2147483647"}}))
+
+			o.Spec.Template.HostNetwork = true
+
+			Expect(podSecurityContext(o)).Should(BeNil())
+		})
+
+		It("Should create a ClusterIP and a headless Service", func() {
+			o := &v1alpha1.Octopinger{}
+			o.Name = "octopinger"
+
+			svcs := services(o)
+			Expect(svcs).Should(HaveLen(2))
+			Expect(svcs[0].Name).Should(Equal("octopinger-service"))
+			Expect(svcs[0].Spec.ClusterIP).Should(BeEmpty())
+			Expect(svcs[1].Name).Should(Equal("octopinger-headless"))
+			Expect(svcs[1].Spec.ClusterIP).Should(Equal(corev1.ClusterIPNone))
+
+			for _, svc := range svcs {
+				Expect(svc.Spec.Ports[0].Port).Should(Equal(int32(octopinger.StatusPort)))
+			}
+		})
+
+		It("Should mount the resolv.conf of the node for the search path", func() {
+			cfg := &v1alpha1.Config{}
+
+			volumes, mounts := nodeResolvConf(cfg)
+			Expect(volumes).Should(BeEmpty())
+			Expect(mounts).Should(BeEmpty())
+
+			cfg.DNS.SearchPath.ResolvConf = v1alpha1.DNSResolvConfNode
+
+			volumes, mounts = nodeResolvConf(cfg)
+			Expect(volumes).Should(HaveLen(2))
+			Expect(volumes[0].HostPath.Path).Should(Equal(octopinger.NodeResolvConfHostPath))
+			Expect(volumes[1].HostPath.Path).Should(Equal(octopinger.NodeResolvedDirHostPath))
+			Expect(mounts).Should(Equal([]corev1.VolumeMount{
+				{Name: volumes[0].Name, MountPath: octopinger.NodeResolvConf, ReadOnly: true},
+				{Name: volumes[1].Name, MountPath: octopinger.NodeResolvedDir, ReadOnly: true},
+			}))
+		})
+
+		It("Should mount the certificate authorities of the TLS targets", func() {
+			cfg := &v1alpha1.Config{}
+			cfg.TLS.Targets = []v1alpha1.TLSTarget{
+				{Address: "www.ionos.com:443"},
+				{Address: "10.0.0.1:443", CAConfigMap: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "tls-ca"}, Key: "a.crt"}},
+				{Address: "10.0.0.2:443", CAConfigMap: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "tls-ca"}, Key: "b.crt"}},
+			}
+
+			volumes, mounts := tlsCA(cfg)
+			Expect(volumes).Should(HaveLen(1))
+			Expect(volumes[0].ConfigMap.Name).Should(Equal("tls-ca"))
+			Expect(mounts).Should(Equal([]corev1.VolumeMount{{Name: volumes[0].Name, MountPath: octopinger.TLSCADir + "/tls-ca", ReadOnly: true}}))
+			Expect(octopinger.TLSCAFile(octopinger.TLSCADir, cfg.TLS.Targets[2])).Should(Equal(octopinger.TLSCADir + "/tls-ca/b.crt"))
+		})
+
+		It("Should mount the certificate authorities of the encrypted name servers", func() {
+			cfg := &v1alpha1.Config{}
+
+			volumes, mounts := dnsCA(cfg)
+			Expect(volumes).Should(BeEmpty())
+			Expect(mounts).Should(BeEmpty())
+
+			cfg.DNS.TLS.CAConfigMap = &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "dns-ca"}, Key: "bundle.pem"}
+
+			volumes, mounts = dnsCA(cfg)
+			Expect(volumes).Should(HaveLen(1))
+			Expect(volumes[0].ConfigMap.Name).Should(Equal("dns-ca"))
+			Expect(volumes[0].ConfigMap.Items).Should(Equal([]corev1.KeyToPath{{Key: "bundle.pem", Path: octopinger.DNSCAFile}}))
+			Expect(mounts).Should(Equal([]corev1.VolumeMount{{Name: volumes[0].Name, MountPath: octopinger.DNSCADir, ReadOnly: true}}))
+		})
+	})
+})
+
+func TestReconcileDaemonSets(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+	g.Expect(v1alpha1.AddToScheme(s)).To(Succeed())
+
+	o := &v1alpha1.Octopinger{
+		ObjectMeta: metav1.ObjectMeta{Name: "octopinger", Namespace: "default", UID: "octopinger"},
+		Spec: v1alpha1.OctopingerSpec{
+			Template: v1alpha1.Template{Image: "octopinger:v1", PriorityClassName: "system-node-critical"},
+		},
+	}
+	o.Spec.Config.ICMP.Socket = v1alpha1.ICMPSocketDatagram
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "octopinger-config", Namespace: "default"},
+		Data:       NewConfigMapData(),
+	}
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(o, configMap).Build()
+	d := &daemonReconciler{Client: c, scheme: s}
+
+	g.Expect(d.reconcileDaemonSets(ctx, o)).To(Succeed())
+
+	ds := &appsv1.DaemonSet{}
+	key := types.NamespacedName{Name: "octopinger-daemonset", Namespace: "default"}
+	g.Expect(c.Get(ctx, key, ds)).To(Succeed())
+	g.Expect(ds.Spec.Template.Spec.PriorityClassName).To(Equal("system-node-critical"))
+	g.Expect(ds.Spec.Template.Spec.SecurityContext.Sysctls).NotTo(BeEmpty())
+	g.Expect(ds.Spec.Template.Spec.Volumes[0].ConfigMap.Items).To(BeEmpty())
+
+	// an unchanged spec does not update the DaemonSet, although the live one carries defaulted fields
+	ds.Spec.RevisionHistoryLimit = ptr.To[int32](10)
+	g.Expect(c.Update(ctx, ds)).To(Succeed())
+	version := ds.ResourceVersion
+
+	g.Expect(d.reconcileDaemonSets(ctx, o)).To(Succeed())
+	g.Expect(c.Get(ctx, key, ds)).To(Succeed())
+	g.Expect(ds.ResourceVersion).To(Equal(version))
+
+	// cleared fields are removed from the DaemonSet
+	o.Spec.Template.PriorityClassName = ""
+	o.Spec.Config.ICMP.Socket = v1alpha1.ICMPSocketRaw
+
+	g.Expect(d.reconcileDaemonSets(ctx, o)).To(Succeed())
+	g.Expect(c.Get(ctx, key, ds)).To(Succeed())
+	g.Expect(ds.ResourceVersion).NotTo(Equal(version))
+	g.Expect(ds.Spec.Template.Spec.PriorityClassName).To(BeEmpty())
+	g.Expect(ds.Spec.Template.Spec.SecurityContext).To(BeNil())
+}
@@ -0,0 +1,594 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DefaultMatrixInterval is the default interval to collect the results of the agents.
+	DefaultMatrixInterval = 30 * time.Second
+	// MatrixPath is the path the matrix is served on the metrics server.
+	MatrixPath = "/api/v1/matrix"
+
+	defaultMatrixTimeout = 5 * time.Second
+	matrixProbe          = "icmp"
+)
+
+var (
+	matrixLoss = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_matrix_loss",
+			Help: "Fraction of lost packets from a source node to a target node, from 0 to 1.",
+		},
+		[]string{
+			"octopinger_name",
+			"octopinger_namespace",
+			"octopinger_network",
+			"octopinger_ip_family",
+			"octopinger_node",
+			"octopinger_target_node",
+		},
+	)
+
+	matrixRttMean = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_matrix_rtt_mean",
+			Help: "Mean round-trip time from a source node to a target node.",
+		},
+		[]string{
+			"octopinger_name",
+			"octopinger_namespace",
+			"octopinger_network",
+			"octopinger_ip_family",
+			"octopinger_node",
+			"octopinger_target_node",
+		},
+	)
+
+	matrixReachable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_matrix_reachable",
+			Help: "Whether a target node is reachable from a source node.",
+		},
+		[]string{
+			"octopinger_name",
+			"octopinger_namespace",
+			"octopinger_network",
+			"octopinger_ip_family",
+			"octopinger_node",
+			"octopinger_target_node",
+		},
+	)
+
+	matrixAsymmetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_matrix_asymmetric",
+			Help: "Set if a target node is not reachable from a source node, but the source node is reachable from the target node.",
+		},
+		[]string{
+			"octopinger_name",
+			"octopinger_namespace",
+			"octopinger_network",
+			"octopinger_ip_family",
+			"octopinger_node",
+			"octopinger_target_node",
+		},
+	)
+
+	matrixAgentsReporting = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_matrix_agents_reporting",
+			Help: "Number of agents that reported results.",
+		},
+		[]string{
+			"octopinger_name",
+			"octopinger_namespace",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		matrixLoss,
+		matrixRttMean,
+		matrixReachable,
+		matrixAsymmetric,
+		matrixAgentsReporting,
+	)
+}
+
+// Link is a directed connection between two nodes.
+type Link struct {
+	// Source is the name of the probing node.
+	Source string `json:"source"`
+	// Target is the name of the probed node.
+	Target string `json:"target"`
+}
+
+// Matrix is the cluster-wide reachability matrix of an Octopinger.
+type Matrix struct {
+	// Octopinger is the name of the Octopinger.
+	Octopinger string `json:"octopinger"`
+	// Namespace is the namespace of the Octopinger.
+	Namespace string `json:"namespace"`
+	// Network is the probed network of the nodes.
+	Network string `json:"network"`
+	// IPFamily is the probed IP family of the nodes.
+	IPFamily string `json:"ip_family"`
+	// Updated is the time the results were collected.
+	Updated time.Time `json:"updated"`
+	// Nodes are the names of the nodes, in the order of the rows and columns.
+	Nodes []string `json:"nodes"`
+	// Loss is the fraction of lost packets from a source (row) to a target (column).
+	// It is null if there is no result.
+	Loss [][]*float64 `json:"loss"`
+	// RttMean is the mean round-trip time in microseconds from a source (row) to a target (column).
+	// It is null if there is no result.
+	RttMean [][]*float64 `json:"rtt_mean"`
+	// Unreachable are the links on which all packets were lost.
+	Unreachable []Link `json:"unreachable"`
+	// Asymmetric are the unreachable links for which the reverse link is reachable.
+	Asymmetric []Link `json:"asymmetric"`
+}
+
+// NewMatrix is building the matrix of a network and an IP family from the results of the agents, keyed by the node name of the agent.
+func NewMatrix(reports map[string]octopinger.ResultsReport, names map[string]string, network v1alpha1.Network, family v1alpha1.IPFamily) *Matrix {
+	m := new(Matrix)
+	m.Network = string(network)
+	m.IPFamily = string(family)
+
+	index := make(map[string]int)
+	add := func(node string) {
+		if _, ok := index[node]; !ok {
+			index[node] = len(m.Nodes)
+			m.Nodes = append(m.Nodes, node)
+		}
+	}
+
+	type cell struct {
+		loss float64
+		rtt  float64
+	}
+
+	cells := make(map[Link]cell)
+
+	for source, report := range reports {
+		add(source)
+
+		for _, p := range report.Probes {
+			if p.Probe != matrixProbe {
+				continue
+			}
+
+			for _, t := range p.Targets {
+				if !inNetwork(t, network) || !inIPFamily(t, family) {
+					continue
+				}
+
+				target := t.TargetNode
+				if target == "" {
+					target = names[t.Target]
+				}
+
+				if target == "" {
+					target = t.Target
+				}
+
+				add(target)
+				cells[Link{Source: source, Target: target}] = cell{loss: t.Loss, rtt: t.RttMean}
+			}
+		}
+	}
+
+	sort.Strings(m.Nodes)
+	for i, n := range m.Nodes {
+		index[n] = i
+	}
+
+	m.Loss = make([][]*float64, len(m.Nodes))
+	m.RttMean = make([][]*float64, len(m.Nodes))
+	m.Unreachable = []Link{}
+	m.Asymmetric = []Link{}
+
+	for i := range m.Nodes {
+		m.Loss[i] = make([]*float64, len(m.Nodes))
+		m.RttMean[i] = make([]*float64, len(m.Nodes))
+	}
+
+	for _, source := range m.Nodes {
+		for _, target := range m.Nodes {
+			l := Link{Source: source, Target: target}
+
+			c, ok := cells[l]
+			if !ok {
+				continue
+			}
+
+			loss, rtt := c.loss, c.rtt
+			m.Loss[index[source]][index[target]] = &loss
+			m.RttMean[index[source]][index[target]] = &rtt
+
+			if loss < 1 {
+				continue
+			}
+
+			m.Unreachable = append(m.Unreachable, l)
+
+			if r, ok := cells[Link{Source: target, Target: source}]; ok && r.loss < 1 {
+				m.Asymmetric = append(m.Asymmetric, l)
+			}
+		}
+	}
+
+	return m
+}
+
+// DegradedNodes returns the nodes that are probed with a packet loss above the threshold.
+func (m *Matrix) DegradedNodes(threshold float64) []string {
+	nodes := []string{}
+
+	for j, target := range m.Nodes {
+		for i := range m.Nodes {
+			if loss := m.Loss[i][j]; loss != nil && *loss > threshold {
+				nodes = append(nodes, target)
+				break
+			}
+		}
+	}
+
+	return nodes
+}
+
+// IsEmpty returns true if there are no results in the matrix.
+func (m *Matrix) IsEmpty() bool {
+	for _, row := range m.Loss {
+		for _, loss := range row {
+			if loss != nil {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// inIPFamily returns true if the result is in the family.
+// Results of older agents without a family are in the family of the target.
+func inIPFamily(r octopinger.Result, family v1alpha1.IPFamily) bool {
+	if r.IPFamily == "" {
+		return octopinger.IPFamilyOf(r.Target) == family
+	}
+
+	return r.IPFamily == string(family)
+}
+
+func inNetwork(r octopinger.Result, network v1alpha1.Network) bool {
+	if r.Network == "" {
+		return network == v1alpha1.NetworkHost
+	}
+
+	return r.Network == string(network)
+}
+
+// NewMatrixCollector ...
+func NewMatrixCollector(mgr manager.Manager, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultMatrixInterval
+	}
+
+	c := &matrixCollector{
+		Client:   mgr.GetClient(),
+		interval: interval,
+		http: &http.Client{
+			Timeout: defaultMatrixTimeout,
+		},
+		matrices: make(map[string]*Matrix),
+	}
+
+	err := mgr.AddMetricsServerExtraHandler(MatrixPath, c)
+	if err != nil {
+		return err
+	}
+
+	return mgr.Add(c)
+}
+
+type matrixCollector struct {
+	client.Client
+
+	interval time.Duration
+	http     *http.Client
+	matrices map[string]*Matrix
+
+	sync.RWMutex
+}
+
+// NeedLeaderElection ...
+func (m *matrixCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Start ...
+func (m *matrixCollector) Start(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.collect(ctx)
+		}
+	}
+}
+
+// ServeHTTP ...
+func (m *matrixCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.RLock()
+	defer m.RUnlock()
+
+	matrices := make([]*Matrix, 0, len(m.matrices))
+	for _, matrix := range m.matrices {
+		matrices = append(matrices, matrix)
+	}
+
+	sort.Slice(matrices, func(i, j int) bool {
+		if matrices[i].Namespace != matrices[j].Namespace {
+			return matrices[i].Namespace < matrices[j].Namespace
+		}
+
+		if matrices[i].Octopinger != matrices[j].Octopinger {
+			return matrices[i].Octopinger < matrices[j].Octopinger
+		}
+
+		if matrices[i].Network != matrices[j].Network {
+			return matrices[i].Network < matrices[j].Network
+		}
+
+		return matrices[i].IPFamily < matrices[j].IPFamily
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(matrices)
+}
+
+func (m *matrixCollector) collect(ctx context.Context) {
+	log := ctrl.LoggerFrom(ctx).WithName("matrix")
+
+	daemonSets := &appsv1.DaemonSetList{}
+	err := m.List(ctx, daemonSets)
+	if err != nil {
+		log.Error(err, "listing daemonsets")
+		return
+	}
+
+	matrices := make(map[string]*Matrix)
+	reporting := make(map[string]bool)
+
+	for i := range daemonSets.Items {
+		ds := &daemonSets.Items[i]
+
+		owner := metav1.GetControllerOf(ds)
+		if owner == nil || owner.Kind != "Octopinger" {
+			continue
+		}
+
+		pods, err := listAgents(ctx, m, ds)
+		if err != nil {
+			log.Error(err, "listing agents", "daemonset", ds.Name, "namespace", ds.Namespace)
+			continue
+		}
+
+		reports, names := m.fetch(ctx, pods)
+
+		reporting[ds.Namespace+"/"+owner.Name] = true
+		matrixAgentsReporting.WithLabelValues(owner.Name, ds.Namespace).Set(float64(len(reports)))
+
+		owned := []*Matrix{}
+
+		for _, network := range []v1alpha1.Network{v1alpha1.NetworkHost, v1alpha1.NetworkPod} {
+			for _, family := range []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4, v1alpha1.IPFamilyIPv6} {
+				matrix := NewMatrix(reports, names, network, family)
+
+				// the pod network and IPv6 are only probed if they are configured
+				if (network != v1alpha1.NetworkHost || family != v1alpha1.IPFamilyIPv4) && matrix.IsEmpty() {
+					continue
+				}
+
+				matrix.Octopinger = owner.Name
+				matrix.Namespace = ds.Namespace
+				matrix.Updated = time.Now()
+
+				matrices[ds.Namespace+"/"+owner.Name+"/"+matrix.Network+"/"+matrix.IPFamily] = matrix
+				owned = append(owned, matrix)
+
+				writeMatrixMetrics(matrix)
+			}
+		}
+
+		err = m.updateStatus(ctx, ds.Namespace, owner.Name, len(reports), owned)
+		if err != nil {
+			log.Error(err, "updating status", "octopinger", owner.Name, "namespace", ds.Namespace)
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	for key, matrix := range m.matrices {
+		if _, ok := matrices[key]; !ok {
+			deleteMatrixMetrics(matrix)
+		}
+
+		if !reporting[matrix.Namespace+"/"+matrix.Octopinger] {
+			matrixAgentsReporting.DeleteLabelValues(matrix.Octopinger, matrix.Namespace)
+		}
+	}
+
+	m.matrices = matrices
+}
+
+func (m *matrixCollector) updateStatus(ctx context.Context, namespace, name string, reporting int, matrices []*Matrix) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		o := &v1alpha1.Octopinger{}
+
+		err := m.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, o)
+		if err != nil {
+			return err
+		}
+
+		// the ready condition is set by the daemon reconciler
+		setConnectivity(o, reporting, matrices)
+
+		return m.Status().Update(ctx, o)
+	})
+}
+
+func (m *matrixCollector) fetch(ctx context.Context, pods *corev1.PodList) (map[string]octopinger.ResultsReport, map[string]string) {
+	log := ctrl.LoggerFrom(ctx).WithName("matrix")
+
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+
+	reports := make(map[string]octopinger.ResultsReport)
+	names := make(map[string]string)
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.Status.HostIP != "" {
+			names[pod.Status.HostIP] = pod.Spec.NodeName
+		}
+
+		if pod.Status.PodIP != "" {
+			names[pod.Status.PodIP] = pod.Spec.NodeName
+		}
+
+		for _, ip := range pod.Status.HostIPs {
+			names[ip.IP] = pod.Spec.NodeName
+		}
+
+		for _, ip := range pod.Status.PodIPs {
+			names[ip.IP] = pod.Spec.NodeName
+		}
+
+		if pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			report, err := m.fetchReport(ctx, pod)
+			if err != nil {
+				log.Error(err, "fetching results", "pod", pod.Name, "node", pod.Spec.NodeName)
+				return
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+
+			reports[pod.Spec.NodeName] = report
+		}()
+	}
+
+	wg.Wait()
+
+	return reports, names
+}
+
+func (m *matrixCollector) fetchReport(ctx context.Context, pod *corev1.Pod) (octopinger.ResultsReport, error) {
+	report := octopinger.ResultsReport{}
+
+	url := fmt.Sprintf("http://%s/api/v1/results", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(statusPort(pod))))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return report, err
+	}
+
+	res, err := m.http.Do(req)
+	if err != nil {
+		return report, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return report, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&report)
+
+	return report, err
+}
+
+func statusPort(pod *corev1.Pod) int {
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == "status" {
+				return int(p.ContainerPort)
+			}
+		}
+	}
+
+	return statusServerPort
+}
+
+func writeMatrixMetrics(matrix *Matrix) {
+	deleteMatrixMetrics(matrix)
+
+	for i, source := range matrix.Nodes {
+		for j, target := range matrix.Nodes {
+			loss := matrix.Loss[i][j]
+			if loss == nil {
+				continue
+			}
+
+			reachable := 0.0
+			if *loss < 1 {
+				reachable = 1
+			}
+
+			matrixLoss.WithLabelValues(matrix.Octopinger, matrix.Namespace, matrix.Network, matrix.IPFamily, source, target).Set(*loss)
+			matrixRttMean.WithLabelValues(matrix.Octopinger, matrix.Namespace, matrix.Network, matrix.IPFamily, source, target).Set(*matrix.RttMean[i][j])
+			matrixReachable.WithLabelValues(matrix.Octopinger, matrix.Namespace, matrix.Network, matrix.IPFamily, source, target).Set(reachable)
+		}
+	}
+
+	for _, l := range matrix.Asymmetric {
+		matrixAsymmetric.WithLabelValues(matrix.Octopinger, matrix.Namespace, matrix.Network, matrix.IPFamily, l.Source, l.Target).Set(1)
+	}
+}
+
+func deleteMatrixMetrics(matrix *Matrix) {
+	labels := prometheus.Labels{"octopinger": matrix.Octopinger, "namespace": matrix.Namespace, "network": matrix.Network, "ip_family": matrix.IPFamily}
+
+	matrixLoss.DeletePartialMatch(labels)
+	matrixRttMean.DeletePartialMatch(labels)
+	matrixReachable.DeletePartialMatch(labels)
+	matrixAsymmetric.DeletePartialMatch(labels)
+}
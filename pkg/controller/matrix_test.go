@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
+
+	. "github.com/onsi/gomega"
+)
+
+// TestNewMatrix does not need the API server of the suite, so it is not a spec.
+func TestNewMatrix(t *testing.T) {
+	g := NewWithT(t)
+
+	reports := map[string]octopinger.ResultsReport{
+		"node-a": {
+			Node: "node-a",
+			Probes: []octopinger.ProbeResults{
+				{
+					Probe: "icmp",
+					Targets: []octopinger.Result{
+						{Target: "10.0.0.2", TargetNode: "node-b", IPFamily: "IPv4", Loss: 1},
+						{Target: "fd00::2", TargetNode: "node-b", IPFamily: "IPv6", Loss: 0},
+					},
+				},
+			},
+		},
+		"node-b": {
+			Node: "node-b",
+			Probes: []octopinger.ProbeResults{
+				{
+					Probe: "icmp",
+					Targets: []octopinger.Result{
+						{Target: "10.0.0.1", Loss: 0, RttMean: 120},
+					},
+				},
+			},
+		},
+	}
+
+	names := map[string]string{
+		"10.0.0.1": "node-a",
+		"10.0.0.2": "node-b",
+	}
+
+	m := NewMatrix(reports, names, v1alpha1.NetworkHost, v1alpha1.IPFamilyIPv4)
+
+	g.Expect(m.Nodes).Should(Equal([]string{"node-a", "node-b"}))
+	g.Expect(m.Loss[0][0]).Should(BeNil())
+	g.Expect(*m.Loss[0][1]).Should(Equal(1.0))
+	g.Expect(*m.Loss[1][0]).Should(Equal(0.0))
+	g.Expect(*m.RttMean[1][0]).Should(Equal(120.0))
+	g.Expect(m.Unreachable).Should(Equal([]Link{{Source: "node-a", Target: "node-b"}}))
+	g.Expect(m.Asymmetric).Should(Equal([]Link{{Source: "node-a", Target: "node-b"}}))
+	g.Expect(m.DegradedNodes(0.05)).Should(Equal([]string{"node-b"}))
+
+	g.Expect(NewMatrix(reports, names, v1alpha1.NetworkPod, v1alpha1.IPFamilyIPv4).IsEmpty()).Should(BeTrue())
+
+	m = NewMatrix(reports, names, v1alpha1.NetworkHost, v1alpha1.IPFamilyIPv6)
+
+	g.Expect(m.Nodes).Should(Equal([]string{"node-a", "node-b"}))
+	g.Expect(*m.Loss[0][1]).Should(Equal(0.0))
+	g.Expect(m.Loss[1][0]).Should(BeNil())
+	g.Expect(m.Unreachable).Should(BeEmpty())
+}
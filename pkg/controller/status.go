@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	defaultPacketLossThreshold = 0.05
+)
+
+// packetLossThreshold returns the threshold of packet loss to report a node as degraded.
+func packetLossThreshold(octopinger *v1alpha1.Octopinger) float64 {
+	t, err := strconv.ParseFloat(octopinger.Spec.Config.ICMP.NodePacketLossThreshold, 64)
+	if err != nil {
+		return defaultPacketLossThreshold
+	}
+
+	return t
+}
+
+// validateConfig is validating the config the same way as the agents.
+func validateConfig(cfg *v1alpha1.Config) error {
+	return octopinger.Config().Validate(cfg)
+}
+
+// setConfigCondition ...
+func setConfigCondition(octopinger *v1alpha1.Octopinger, err error) {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionConfigValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		ObservedGeneration: octopinger.Generation,
+	}
+
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = err.Error()
+	}
+
+	meta.SetStatusCondition(&octopinger.Status.Conditions, condition)
+}
+
+// setDaemonSetCondition ...
+func setDaemonSetCondition(octopinger *v1alpha1.Octopinger, ds *appsv1.DaemonSet) {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionDaemonSetAvailable,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NotFound",
+		ObservedGeneration: octopinger.Generation,
+	}
+
+	octopinger.Status.DesiredAgents = 0
+	octopinger.Status.ReadyAgents = 0
+
+	if ds != nil {
+		octopinger.Status.DesiredAgents = ds.Status.DesiredNumberScheduled
+		octopinger.Status.ReadyAgents = ds.Status.NumberReady
+
+		condition.Reason = "Unavailable"
+		condition.Message = fmt.Sprintf("%d/%d agents available", ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled)
+
+		if ds.Status.DesiredNumberScheduled > 0 && ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "Available"
+		}
+	}
+
+	meta.SetStatusCondition(&octopinger.Status.Conditions, condition)
+}
+
+// setConnectivity is setting the summary of the matrices and the degraded condition.
+func setConnectivity(octopinger *v1alpha1.Octopinger, reporting int, matrices []*Matrix) {
+	threshold := packetLossThreshold(octopinger)
+
+	connectivity := &v1alpha1.Connectivity{
+		Updated:         metav1.Now(),
+		ReportingAgents: int32(reporting),
+	}
+
+	degraded := make(map[string]bool)
+	for _, m := range matrices {
+		connectivity.UnreachableLinks += int32(len(m.Unreachable))
+
+		for _, node := range m.DegradedNodes(threshold) {
+			if !degraded[node] {
+				degraded[node] = true
+				connectivity.DegradedNodeNames = append(connectivity.DegradedNodeNames, node)
+			}
+		}
+	}
+	connectivity.DegradedNodes = int32(len(connectivity.DegradedNodeNames))
+
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoPacketLoss",
+		ObservedGeneration: octopinger.Generation,
+	}
+
+	if connectivity.DegradedNodes > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "PacketLoss"
+		condition.Message = fmt.Sprintf("%d nodes with packet loss above %g", connectivity.DegradedNodes, threshold)
+	}
+
+	octopinger.Status.Connectivity = connectivity
+	meta.SetStatusCondition(&octopinger.Status.Conditions, condition)
+}
+
+// setReadyCondition is summarizing all other conditions.
+func setReadyCondition(octopinger *v1alpha1.Octopinger) {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Ready",
+		ObservedGeneration: octopinger.Generation,
+	}
+
+	conditions := octopinger.Status.Conditions
+
+	switch {
+	case !meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionConfigValid):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ConfigInvalid"
+	case !meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionDaemonSetAvailable):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "DaemonSetUnavailable"
+	case meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionDegraded):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Degraded"
+	}
+
+	meta.SetStatusCondition(&octopinger.Status.Conditions, condition)
+}
+
+// degradedChanged is passing the updates of the degraded condition by the matrix collector,
+// so that the ready condition is only set by the daemon reconciler.
+func degradedChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			o, ok := e.ObjectOld.(*v1alpha1.Octopinger)
+			if !ok {
+				return false
+			}
+
+			n, ok := e.ObjectNew.(*v1alpha1.Octopinger)
+			if !ok {
+				return false
+			}
+
+			return meta.IsStatusConditionTrue(o.Status.Conditions, v1alpha1.ConditionDegraded) != meta.IsStatusConditionTrue(n.Status.Conditions, v1alpha1.ConditionDegraded)
+		},
+	}
+}
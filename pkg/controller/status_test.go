@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Status", func() {
+	Context("When setting the conditions", func() {
+		It("Should only be ready if all conditions are met", func() {
+			o := &v1alpha1.Octopinger{}
+
+			setConfigCondition(o, errors.New("invalid network"))
+			setDaemonSetCondition(o, nil)
+			setReadyCondition(o)
+			Expect(meta.FindStatusCondition(o.Status.Conditions, v1alpha1.ConditionReady).Reason).Should(Equal("ConfigInvalid"))
+
+			ds := &appsv1.DaemonSet{}
+			ds.Status.DesiredNumberScheduled = 2
+			ds.Status.NumberAvailable = 2
+			ds.Status.NumberReady = 2
+
+			setConfigCondition(o, nil)
+			setDaemonSetCondition(o, ds)
+			setReadyCondition(o)
+			Expect(meta.IsStatusConditionTrue(o.Status.Conditions, v1alpha1.ConditionReady)).Should(BeTrue())
+			Expect(o.Status.ReadyAgents).Should(Equal(int32(2)))
+
+			loss := 0.5
+			setConnectivity(o, 2, []*Matrix{{Nodes: []string{"node-a", "node-b"}, Loss: [][]*float64{{nil, &loss}, {nil, nil}}}})
+			setReadyCondition(o)
+			Expect(meta.IsStatusConditionTrue(o.Status.Conditions, v1alpha1.ConditionDegraded)).Should(BeTrue())
+			Expect(meta.IsStatusConditionTrue(o.Status.Conditions, v1alpha1.ConditionReady)).Should(BeFalse())
+			Expect(o.Status.Connectivity.DegradedNodeNames).Should(Equal([]string{"node-b"}))
+		})
+
+		It("Should reconcile the ready condition if the degraded condition changes", func() {
+			o := &v1alpha1.Octopinger{}
+			setConnectivity(o, 2, nil)
+
+			n := o.DeepCopy()
+			Expect(degradedChanged().Update(event.UpdateEvent{ObjectOld: o, ObjectNew: n})).Should(BeFalse())
+
+			loss := 0.5
+			setConnectivity(n, 2, []*Matrix{{Nodes: []string{"node-a", "node-b"}, Loss: [][]*float64{{nil, &loss}, {nil, nil}}}})
+			Expect(degradedChanged().Update(event.UpdateEvent{ObjectOld: o, ObjectNew: n})).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/ionos-cloud/octopinger/pkg/octopinger"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-octopinger-io-v1alpha1-octopinger,mutating=true,failurePolicy=fail,sideEffects=None,groups=octopinger.io,resources=octopingers,verbs=create;update,versions=v1alpha1,name=moctopinger.octopinger.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-octopinger-io-v1alpha1-octopinger,mutating=false,failurePolicy=fail,sideEffects=None,groups=octopinger.io,resources=octopingers,verbs=create;update,versions=v1alpha1,name=voctopinger.octopinger.io,admissionReviewVersions=v1
+
+// NewOctopingerWebhook is registering the defaulting and validating webhooks of Octopinger.
+func NewOctopingerWebhook(mgr manager.Manager) error {
+	w := &octopingerWebhook{}
+
+	return ctrl.NewWebhookManagedBy(mgr, &v1alpha1.Octopinger{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+type octopingerWebhook struct{}
+
+// Default ...
+func (w *octopingerWebhook) Default(_ context.Context, o *v1alpha1.Octopinger) error {
+	octopinger.Config().Default(&o.Spec.Config)
+
+	return nil
+}
+
+// ValidateCreate ...
+func (w *octopingerWebhook) ValidateCreate(_ context.Context, o *v1alpha1.Octopinger) (admission.Warnings, error) {
+	return nil, w.validate(o)
+}
+
+// ValidateUpdate ...
+func (w *octopingerWebhook) ValidateUpdate(_ context.Context, _, o *v1alpha1.Octopinger) (admission.Warnings, error) {
+	return nil, w.validate(o)
+}
+
+// ValidateDelete ...
+func (w *octopingerWebhook) ValidateDelete(_ context.Context, _ *v1alpha1.Octopinger) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *octopingerWebhook) validate(o *v1alpha1.Octopinger) error {
+	errs := field.ErrorList{}
+
+	if o.Spec.Template.Image == "" {
+		errs = append(errs, field.Required(field.NewPath("spec", "template", "image"), "image must not be empty"))
+	}
+
+	if err := validateConfig(&o.Spec.Config); err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "config"), field.OmitValueType{}, err.Error()))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind(v1alpha1.CRDResourceKind).GroupKind(), o.Name, errs)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Octopinger webhook", func() {
+	const (
+		OctopingerNamespace = "default"
+	)
+
+	newOctopinger := func(name string) *v1alpha1.Octopinger {
+		return &v1alpha1.Octopinger{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: OctopingerNamespace,
+			},
+			Spec: v1alpha1.OctopingerSpec{
+				Template: v1alpha1.Template{
+					Image: "ghcr.io/ionos-cloud/octopinger/octopinger:v0.1.12",
+				},
+				Config: v1alpha1.Config{
+					ICMP: v1alpha1.ICMP{
+						Enable: true,
+					},
+				},
+			},
+		}
+	}
+
+	Context("When creating an Octopinger", func() {
+		It("Should set the defaults", func() {
+			ctx := context.Background()
+
+			o := newOctopinger("test-defaults")
+			Expect(k8sClient.Create(ctx, o)).Should(Succeed())
+
+			created := &v1alpha1.Octopinger{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: o.Name, Namespace: o.Namespace}, created)).Should(Succeed())
+
+			Expect(created.Spec.Config.ICMP.Timeout).Should(Equal("5s"))
+			Expect(created.Spec.Config.ICMP.Count).Should(Equal(5))
+			Expect(created.Spec.Config.ICMP.NodePacketLossThreshold).Should(Equal("0.05"))
+			Expect(created.Spec.Config.DNS.Timeout).Should(Equal("3s"))
+		})
+
+		It("Should reject an invalid config", func() {
+			ctx := context.Background()
+
+			o := newOctopinger("test-invalid-timeout")
+			o.Spec.Config.ICMP.Timeout = "soon"
+			Expect(apierrors.IsInvalid(k8sClient.Create(ctx, o))).Should(BeTrue())
+
+			o = newOctopinger("test-invalid-threshold")
+			o.Spec.Config.ICMP.NodePacketLossThreshold = "1.5"
+			Expect(apierrors.IsInvalid(k8sClient.Create(ctx, o))).Should(BeTrue())
+
+			o = newOctopinger("test-invalid-count")
+			o.Spec.Config.ICMP.Count = 1000
+			Expect(apierrors.IsInvalid(k8sClient.Create(ctx, o))).Should(BeTrue())
+
+			o = newOctopinger("test-invalid-target")
+			o.Spec.Config.TCP = v1alpha1.TCP{Enable: true, Targets: []string{"www.ionos.com"}}
+			Expect(apierrors.IsInvalid(k8sClient.Create(ctx, o))).Should(BeTrue())
+		})
+
+		It("Should reject an empty image", func() {
+			ctx := context.Background()
+
+			o := newOctopinger("test-invalid-image")
+			o.Spec.Template.Image = ""
+			Expect(apierrors.IsInvalid(k8sClient.Create(ctx, o))).Should(BeTrue())
+		})
+	})
+})
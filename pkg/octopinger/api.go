@@ -13,7 +13,9 @@ import (
 )
 
 type api struct {
-	addr string
+	addr     string
+	instance Instance
+	results  *Results
 	srv.Listener
 }
 
@@ -27,9 +29,24 @@ func WithAddr(addr string) APIOpt {
 	}
 }
 
+// WithResults ...
+func WithResults(results *Results) APIOpt {
+	return func(a *api) {
+		a.results = results
+	}
+}
+
+// WithInstance ...
+func WithInstance(nodeName string) APIOpt {
+	return func(a *api) {
+		a.instance = Instance{Node: nodeName}
+	}
+}
+
 // NewAPI ...
 func NewAPI(opts ...APIOpt) *api {
 	a := new(api)
+	a.results = NewResults()
 
 	for _, opt := range opts {
 		opt(a)
@@ -57,6 +74,16 @@ func (a *api) Start(ctx context.Context, ready srv.ReadyFunc, run srv.RunFunc) f
 			return c.SendString("OK")
 		})
 
+		v1 := app.Group("/api/v1")
+
+		v1.Get("/results", func(c *fiber.Ctx) error {
+			return c.JSON(a.results.Report())
+		})
+
+		v1.Get("/instance", func(c *fiber.Ctx) error {
+			return c.JSON(a.instance)
+		})
+
 		go func() {
 			<-ctx.Done()
 			_ = app.Shutdown()
@@ -2,53 +2,254 @@ package octopinger
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"golang.org/x/net/dns/dnsmessage"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+// Target is a single probed node.
+type Target struct {
+	// IP is the address that is probed, or the host name of a TCP target.
+	IP string
+	// Node is the name of the Kubernetes node, if known.
+	Node string
+	// Network is the network of the address.
+	Network v1alpha1.Network
+	// Family is the IP family of the address.
+	Family v1alpha1.IPFamily
+	// Zone is the topology zone of the node, if known.
+	Zone string
+	// Region is the topology region of the node, if known.
+	Region string
+	// Port is the port to connect to, if any.
+	Port int
+}
+
+// Address returns the address to connect to, which is the IP with the port, if any.
+func (t Target) Address() string {
+	if t.Port == 0 {
+		return t.IP
+	}
+
+	return net.JoinHostPort(t.IP, strconv.Itoa(t.Port))
+}
+
+// Node is an entry of the "nodes" file.
+type Node struct {
+	// Name is the name of the Kubernetes node.
+	Name string `json:"name"`
+	// HostIPs are the IPs of the node, one per family.
+	HostIPs []string `json:"hostIPs,omitempty"`
+	// PodIPs are the IPs of the Octopinger pod on the node, one per family.
+	PodIPs []string `json:"podIPs,omitempty"`
+	// Zone is the topology zone of the node.
+	Zone string `json:"zone,omitempty"`
+	// Region is the topology region of the node.
+	Region string `json:"region,omitempty"`
+}
+
+// Targets returns the targets of the node.
+// The pod IPs are omitted if the pod is running in the network of the host.
+func (n Node) Targets() []Target {
+	targets := make([]Target, 0, len(n.HostIPs)+len(n.PodIPs))
+
+	host := make(map[string]bool, len(n.HostIPs))
+	for _, ip := range n.HostIPs {
+		host[ip] = true
+		targets = append(targets, Target{IP: ip, Node: n.Name, Network: v1alpha1.NetworkHost, Family: IPFamilyOf(ip), Zone: n.Zone, Region: n.Region})
+	}
+
+	for _, ip := range n.PodIPs {
+		if host[ip] {
+			continue
+		}
+		targets = append(targets, Target{IP: ip, Node: n.Name, Network: v1alpha1.NetworkPod, Family: IPFamilyOf(ip), Zone: n.Zone, Region: n.Region})
+	}
+
+	return targets
+}
+
 // NodeFilter ...
-type NodeFilter func(node string) bool
+type NodeFilter func(target Target) bool
 
-// FilterIP ...
-func FilterIP(ip string) NodeFilter {
-	return func(node string) bool {
-		return node == ip
+// FilterIP is filtering the targets with one of the IPs.
+func FilterIP(ips ...string) NodeFilter {
+	return func(target Target) bool {
+		for _, ip := range ips {
+			if target.IP == ip {
+				return true
+			}
+		}
+
+		return false
 	}
 }
 
-// NodeLoader ...
-type NodeLoader func() ([]string, error)
+// FilterNode is filtering the targets of the node.
+func FilterNode(nodeName string) NodeFilter {
+	return func(target Target) bool {
+		return target.Node != "" && target.Node == nodeName
+	}
+}
+
+// FilterIPFamily is filtering the targets that are not in one of the families.
+func FilterIPFamily(families ...v1alpha1.IPFamily) NodeFilter {
+	return func(target Target) bool {
+		for _, f := range families {
+			if target.Family == f {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// IPFamilyOf returns the family of the IP, or an empty family if it is not an IP.
+func IPFamilyOf(ip string) v1alpha1.IPFamily {
+	addr := net.ParseIP(ip)
+
+	switch {
+	case addr == nil:
+		return ""
+	case addr.To4() != nil:
+		return v1alpha1.IPFamilyIPv4
+	default:
+		return v1alpha1.IPFamilyIPv6
+	}
+}
+
+// FamilyNetwork returns the network of the family to dial or resolve, e.g. "tcp4" or "ip6".
+func FamilyNetwork(network string, family v1alpha1.IPFamily) string {
+	switch family {
+	case v1alpha1.IPFamilyIPv4:
+		return network + "4"
+	case v1alpha1.IPFamilyIPv6:
+		return network + "6"
+	default:
+		return network
+	}
+}
+
+// IPFamilies returns the configured families, or the families of the IPs by default.
+func IPFamilies(families []v1alpha1.IPFamily, ips ...string) []v1alpha1.IPFamily {
+	if len(families) > 0 {
+		return families
+	}
+
+	res := make([]v1alpha1.IPFamily, 0, 2)
+	for _, f := range []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4, v1alpha1.IPFamilyIPv6} {
+		for _, ip := range ips {
+			if IPFamilyOf(ip) == f {
+				res = append(res, f)
+				break
+			}
+		}
+	}
+
+	// fallback, if the IPs are not known
+	if len(res) == 0 {
+		res = append(res, v1alpha1.IPFamilyIPv4)
+	}
+
+	return res
+}
+
+// FilterNetwork is filtering the targets that are not in the network.
+func FilterNetwork(network v1alpha1.Network) NodeFilter {
+	return func(target Target) bool {
+		switch network {
+		case v1alpha1.NetworkBoth:
+			return false
+		case v1alpha1.NetworkPod:
+			return target.Network != v1alpha1.NetworkPod
+		default:
+			return target.Network != v1alpha1.NetworkHost
+		}
+	}
+}
 
 // NodeLoader ...
+type NodeLoader func() ([]Target, error)
+
+// NodesLoader is loading the targets from the "nodes" file.
+// The file contains a JSON list of nodes, or a plain list with a line for each IP
+// and optionally the name and the network of the node.
 func NodesLoader(base string) NodeLoader {
-	return func() ([]string, error) {
+	return func() ([]Target, error) {
 		p := path.Clean(path.Join(base, "nodes"))
-		nodes := make([]string, 0)
 
-		f, err := os.Open(p)
+		bb, err := os.ReadFile(p)
 		if err != nil {
 			return nil, err
 		}
-		defer func() {
-		    _ = f.Close()
-		}()
 
-		scanner := bufio.NewScanner(f)
+		if strings.HasPrefix(strings.TrimSpace(string(bb)), "[") {
+			return parseNodes(bb)
+		}
+
+		return parseNodeLines(bb)
+	}
+}
+
+func parseNodes(bb []byte) ([]Target, error) {
+	nodes := make([]Node, 0)
+
+	err := json.Unmarshal(bb, &nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(nodes))
+	for _, n := range nodes {
+		targets = append(targets, n.Targets()...)
+	}
+
+	return targets, nil
+}
+
+func parseNodeLines(bb []byte) ([]Target, error) {
+	targets := make([]Target, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(bb))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		target := Target{IP: fields[0], Network: v1alpha1.NetworkHost, Family: IPFamilyOf(fields[0])}
+		if len(fields) > 1 {
+			target.Node = fields[1]
+		}
 
-		for scanner.Scan() {
-			nodes = append(nodes, scanner.Text())
+		if len(fields) > 2 {
+			target.Network = v1alpha1.Network(fields[2])
 		}
 
-		return nodes, nil
+		targets = append(targets, target)
 	}
+
+	return targets, scanner.Err()
 }
 
 // Load ...
-func (n *NodeList) Load() ([]string, error) {
-	nodes := make([]string, 0)
+func (n *NodeList) Load() ([]Target, error) {
+	nodes := make([]Target, 0)
 
 	for _, loader := range n.loaders {
 		n, err := loader()
@@ -84,6 +285,60 @@ func NewNodeList(loaders []NodeLoader, filters ...NodeFilter) *NodeList {
 	return n
 }
 
+// DefaultDNSService is the cluster DNS Service of kubeadm and most distributions.
+const DefaultDNSService = "kube-system/kube-dns"
+
+// DNSEndpoint is an entry of the "dns_endpoints" file.
+type DNSEndpoint struct {
+	// Name is the name of the pod of the endpoint.
+	Name string `json:"name"`
+	// Node is the name of the node of the endpoint.
+	Node string `json:"node,omitempty"`
+	// IPs are the IPs of the endpoint.
+	IPs []string `json:"ips"`
+	// Port is the DNS port of the endpoint.
+	Port int `json:"port"`
+}
+
+// DNSEndpointsLoader is loading the endpoints of the cluster DNS Service from the "dns_endpoints" file.
+// The endpoints are empty, if the file does not exist.
+func DNSEndpointsLoader(base string) func() ([]DNSEndpoint, error) {
+	return func() ([]DNSEndpoint, error) {
+		p := path.Clean(path.Join(base, "dns_endpoints"))
+
+		endpoints := make([]DNSEndpoint, 0)
+
+		bb, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) || (err == nil && len(bytes.TrimSpace(bb)) == 0) {
+			return endpoints, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(bb, &endpoints)
+		if err != nil {
+			return nil, err
+		}
+
+		return endpoints, nil
+	}
+}
+
+// DNSService returns the namespace and the name of the cluster DNS Service.
+func DNSService(service string) (string, string, error) {
+	if service == "" {
+		service = DefaultDNSService
+	}
+
+	namespace, name, ok := strings.Cut(service, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid dns service: %s", service)
+	}
+
+	return namespace, name, nil
+}
+
 type config struct{}
 
 // Config ...
@@ -106,5 +361,330 @@ func (c config) Load(base string) (*v1alpha1.Config, error) {
 		return nil, err
 	}
 
+	err = c.Validate(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
+
+// Default is setting the defaults of the probes in the config.
+func (c config) Default(cfg *v1alpha1.Config) {
+	if cfg.ICMP.Timeout == "" {
+		cfg.ICMP.Timeout = defaultTimeout.String()
+	}
+
+	if cfg.ICMP.Count == 0 {
+		cfg.ICMP.Count = defaultICMPCount
+	}
+
+	if cfg.ICMP.NodePacketLossThreshold == "" {
+		cfg.ICMP.NodePacketLossThreshold = strconv.FormatFloat(defaultPacketLossThreshold, 'f', -1, 64)
+	}
+
+	if cfg.DNS.Timeout == "" {
+		cfg.DNS.Timeout = defaultDNSTimeout.String()
+	}
+
+	if cfg.TCP.Timeout == "" {
+		cfg.TCP.Timeout = defaultTimeout.String()
+	}
+
+	if cfg.Service.Timeout == "" {
+		cfg.Service.Timeout = defaultTimeout.String()
+	}
+
+	if cfg.MTU.Timeout == "" {
+		cfg.MTU.Timeout = defaultMTUTimeout.String()
+	}
+
+	if cfg.MTU.Expected == 0 {
+		cfg.MTU.Expected = defaultMTU
+	}
+
+	for i := range cfg.HTTP.Targets {
+		if cfg.HTTP.Targets[i].Timeout == "" {
+			cfg.HTTP.Targets[i].Timeout = defaultTimeout.String()
+		}
+	}
+
+	for i := range cfg.TLS.Targets {
+		if cfg.TLS.Targets[i].Timeout == "" {
+			cfg.TLS.Targets[i].Timeout = defaultTimeout.String()
+		}
+	}
+}
+
+// Validate is checking the config before it is used by the probes.
+func (c config) Validate(cfg *v1alpha1.Config) error {
+	switch cfg.Network {
+	case "", v1alpha1.NetworkHost, v1alpha1.NetworkPod, v1alpha1.NetworkBoth:
+	default:
+		return fmt.Errorf("invalid network: %s", cfg.Network)
+	}
+
+	for _, family := range cfg.IPFamilies {
+		switch family {
+		case v1alpha1.IPFamilyIPv4, v1alpha1.IPFamilyIPv6:
+		default:
+			return fmt.Errorf("invalid ip family: %s", family)
+		}
+	}
+
+	for _, interval := range []string{cfg.ICMP.Interval, cfg.DNS.Interval, cfg.TCP.Interval, cfg.HTTP.Interval, cfg.TLS.Interval, cfg.Service.Interval, cfg.MTU.Interval} {
+		if _, err := parseInterval(interval); err != nil {
+			return err
+		}
+	}
+
+	for _, timeout := range []string{cfg.ICMP.Timeout, cfg.DNS.Timeout, cfg.TCP.Timeout, cfg.Service.Timeout, cfg.MTU.Timeout} {
+		if err := validateTimeout(timeout); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ICMP.NodePacketLossThreshold != "" {
+		t, err := strconv.ParseFloat(cfg.ICMP.NodePacketLossThreshold, 64)
+		if err != nil {
+			return err
+		}
+
+		if t < 0 || t > 1 {
+			return fmt.Errorf("packet loss threshold must be between 0 and 1: %s", cfg.ICMP.NodePacketLossThreshold)
+		}
+	}
+
+	if cfg.ICMP.Count < 0 || cfg.ICMP.Count > maxICMPCount {
+		return fmt.Errorf("count must be between 0 and %d: %d", maxICMPCount, cfg.ICMP.Count)
+	}
+
+	for _, target := range cfg.ICMP.AdditionalTargets {
+		if err := validateHost(target); err != nil {
+			return err
+		}
+	}
+
+	switch cfg.ICMP.Socket {
+	case "", v1alpha1.ICMPSocketAuto, v1alpha1.ICMPSocketDatagram, v1alpha1.ICMPSocketRaw:
+	default:
+		return fmt.Errorf("invalid icmp socket: %s", cfg.ICMP.Socket)
+	}
+
+	for _, name := range cfg.DNS.Names {
+		if err := validateHost(name); err != nil {
+			return err
+		}
+	}
+
+	if cfg.DNS.Server != "" {
+		if err := validateDNSServer(cfg.DNS.Server); err != nil {
+			return err
+		}
+	}
+
+	if _, _, err := DNSService(cfg.DNS.Service); err != nil {
+		return err
+	}
+
+	for _, check := range cfg.DNS.Checks {
+		if err := validateDNSCheck(check); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range cfg.DNS.SearchPath.Names {
+		if err := validateHost(name); err != nil {
+			return err
+		}
+	}
+
+	switch cfg.DNS.SearchPath.ResolvConf {
+	case "", v1alpha1.DNSResolvConfPod, v1alpha1.DNSResolvConfNode:
+	default:
+		return fmt.Errorf("invalid resolv.conf: %s", cfg.DNS.SearchPath.ResolvConf)
+	}
+
+	if cfg.TCP.NodePort < 0 || cfg.TCP.NodePort > 65535 {
+		return fmt.Errorf("invalid node port: %d", cfg.TCP.NodePort)
+	}
+
+	for _, target := range cfg.TCP.Targets {
+		if err := validateAddress(target); err != nil {
+			return err
+		}
+	}
+
+	if _, err := parseBuckets(cfg.Histograms.Buckets); err != nil {
+		return err
+	}
+
+	for _, size := range cfg.MTU.Sizes {
+		if size < minIPv4MTU || size > maxMTU {
+			return fmt.Errorf("mtu size must be between %d and %d: %d", minIPv4MTU, maxMTU, size)
+		}
+	}
+
+	if cfg.MTU.Expected != 0 && (cfg.MTU.Expected < minIPv4MTU || cfg.MTU.Expected > maxMTU) {
+		return fmt.Errorf("expected mtu must be between %d and %d: %d", minIPv4MTU, maxMTU, cfg.MTU.Expected)
+	}
+
+	for _, target := range cfg.HTTP.Targets {
+		u, err := url.Parse(target.URL)
+		if err != nil {
+			return err
+		}
+
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url: %s", target.URL)
+		}
+
+		if _, err := NewHTTPCheck(target); err != nil {
+			return err
+		}
+	}
+
+	// the CA files are not read, because the config is also validated by the operator.
+	for _, target := range cfg.TLS.Targets {
+		if err := validateAddress(target.Address); err != nil {
+			return err
+		}
+
+		if err := validateTimeout(target.Timeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseBuckets is parsing the upper bounds of the histogram buckets in seconds,
+// which have to be positive and increasing.
+func parseBuckets(buckets []string) ([]float64, error) {
+	bounds := make([]float64, 0, len(buckets))
+
+	for _, b := range buckets {
+		f, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if f <= 0 {
+			return nil, fmt.Errorf("bucket must be positive: %s", b)
+		}
+
+		if len(bounds) > 0 && f <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("buckets must be increasing: %s", b)
+		}
+
+		bounds = append(bounds, f)
+	}
+
+	return bounds, nil
+}
+
+func validateTimeout(timeout string) error {
+	if timeout == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return err
+	}
+
+	if d <= 0 {
+		return fmt.Errorf("timeout must be positive: %s", timeout)
+	}
+
+	return nil
+}
+
+func validateDNSCheck(check v1alpha1.DNSCheck) error {
+	recordType := check.Type
+	if recordType == "" {
+		recordType = v1alpha1.DNSRecordTypeA
+	}
+
+	qtype, ok := dnsRecordTypes[recordType]
+	if !ok {
+		return fmt.Errorf("invalid record type: %s", check.Type)
+	}
+
+	if _, err := newDNSQuery(check.Name, qtype); err != nil || check.Name == "" || strings.ContainsAny(check.Name, " \t") {
+		return fmt.Errorf("invalid dns name: %s", check.Name)
+	}
+
+	if check.Server != "" {
+		if err := validateDNSServer(check.Server); err != nil {
+			return err
+		}
+	}
+
+	if check.ExpectedAnswerCount < 0 {
+		return fmt.Errorf("expected answer count must not be negative: %d", check.ExpectedAnswerCount)
+	}
+
+	for _, answer := range check.ExpectedAnswers {
+		if qtype != dnsmessage.TypeA && qtype != dnsmessage.TypeAAAA {
+			continue
+		}
+
+		ip, err := netip.ParseAddr(answer)
+		if err != nil || ip.Is4() != (qtype == dnsmessage.TypeA) {
+			return fmt.Errorf("invalid answer for %s records: %s", recordType, answer)
+		}
+	}
+
+	return nil
+}
+
+// validateDNSServer is validating the address of a plain server, or the URL of an encrypted server.
+func validateDNSServer(server string) error {
+	transport, address, err := parseDNSServer(server)
+	if err != nil {
+		return err
+	}
+
+	if transport == DNSTransportDoH {
+		u, err := url.Parse(address)
+		if err != nil {
+			return err
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "443"
+		}
+
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	return validateAddress(address)
+}
+
+func validateAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port: %s", address)
+	}
+
+	return validateHost(host)
+}
+
+func validateHost(host string) error {
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	if errs := validation.IsDNS1123Subdomain(strings.ToLower(strings.TrimSuffix(host, "."))); len(errs) > 0 {
+		return fmt.Errorf("invalid host %s: %s", host, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
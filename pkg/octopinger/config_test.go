@@ -0,0 +1,180 @@
+package octopinger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodesLoader(t *testing.T) {
+	dir := t.TempDir()
+
+	nodes := "10.0.0.1\n10.0.0.2 node-b\n10.0.0.3 node-c host\n10.244.0.3 node-c pod\n"
+	err := os.WriteFile(filepath.Join(dir, "nodes"), []byte(nodes), 0o600)
+	assert.NoError(t, err)
+
+	list := NewNodeList([]NodeLoader{NodesLoader(dir)}, FilterIP("10.0.0.1"), FilterNetwork(v1alpha1.NetworkBoth))
+
+	targets, err := list.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Target{
+		{IP: "10.0.0.2", Node: "node-b", Network: v1alpha1.NetworkHost, Family: v1alpha1.IPFamilyIPv4},
+		{IP: "10.0.0.3", Node: "node-c", Network: v1alpha1.NetworkHost, Family: v1alpha1.IPFamilyIPv4},
+		{IP: "10.244.0.3", Node: "node-c", Network: v1alpha1.NetworkPod, Family: v1alpha1.IPFamilyIPv4},
+	}, targets)
+
+	list = NewNodeList([]NodeLoader{NodesLoader(dir)}, FilterNetwork(v1alpha1.NetworkPod))
+
+	targets, err = list.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Target{
+		{IP: "10.244.0.3", Node: "node-c", Network: v1alpha1.NetworkPod, Family: v1alpha1.IPFamilyIPv4},
+	}, targets)
+}
+
+func TestNodesLoaderJSON(t *testing.T) {
+	dir := t.TempDir()
+
+	nodes := `[
+		{"name": "node-a", "hostIPs": ["10.0.0.1"], "podIPs": ["10.244.0.1"], "zone": "de-fra-1"},
+		{"name": "node-b", "hostIPs": ["10.0.0.2", "fd00::2"], "podIPs": ["10.0.0.2"], "zone": "de-fra-2", "region": "de-fra"}
+	]`
+	err := os.WriteFile(filepath.Join(dir, "nodes"), []byte(nodes), 0o600)
+	assert.NoError(t, err)
+
+	list := NewNodeList([]NodeLoader{NodesLoader(dir)}, FilterIP("10.0.0.1"), FilterNetwork(v1alpha1.NetworkBoth))
+
+	targets, err := list.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Target{
+		{IP: "10.244.0.1", Node: "node-a", Network: v1alpha1.NetworkPod, Family: v1alpha1.IPFamilyIPv4, Zone: "de-fra-1"},
+		{IP: "10.0.0.2", Node: "node-b", Network: v1alpha1.NetworkHost, Family: v1alpha1.IPFamilyIPv4, Zone: "de-fra-2", Region: "de-fra"},
+		{IP: "fd00::2", Node: "node-b", Network: v1alpha1.NetworkHost, Family: v1alpha1.IPFamilyIPv6, Zone: "de-fra-2", Region: "de-fra"},
+	}, targets)
+}
+
+func TestIPFamilies(t *testing.T) {
+	assert.Equal(t, v1alpha1.IPFamilyIPv4, IPFamilyOf("10.0.0.1"))
+	assert.Equal(t, v1alpha1.IPFamilyIPv6, IPFamilyOf("fd00::1"))
+	assert.Equal(t, v1alpha1.IPFamily(""), IPFamilyOf("www.ionos.com"))
+
+	assert.Equal(t, []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4, v1alpha1.IPFamilyIPv6}, IPFamilies(nil, "fd00::1", "10.0.0.1"))
+	assert.Equal(t, []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv6}, IPFamilies(nil, "fd00::1"))
+	assert.Equal(t, []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4}, IPFamilies(nil))
+	assert.Equal(t, []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv6}, IPFamilies([]v1alpha1.IPFamily{v1alpha1.IPFamilyIPv6}, "10.0.0.1"))
+
+	assert.Equal(t, "tcp6", FamilyNetwork("tcp", v1alpha1.IPFamilyIPv6))
+	assert.Equal(t, "ip", FamilyNetwork("ip", ""))
+
+	list := NewNodeList([]NodeLoader{func() ([]Target, error) {
+		return Node{Name: "node-a", HostIPs: []string{"10.0.0.1", "fd00::1"}}.Targets(), nil
+	}}, FilterIPFamily(v1alpha1.IPFamilyIPv6))
+
+	targets, err := list.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Target{{IP: "fd00::1", Node: "node-a", Network: v1alpha1.NetworkHost, Family: v1alpha1.IPFamilyIPv6}}, targets)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  v1alpha1.Config
+		err  bool
+	}{
+		{name: "empty", cfg: v1alpha1.Config{}},
+		{name: "network", cfg: v1alpha1.Config{Network: "overlay"}, err: true},
+		{name: "ip family", cfg: v1alpha1.Config{IPFamilies: []v1alpha1.IPFamily{"IPv5"}}, err: true},
+		{name: "interval", cfg: v1alpha1.Config{ICMP: v1alpha1.ICMP{Interval: "-1s"}}, err: true},
+		{name: "timeout", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Timeout: "soon"}}, err: true},
+		{name: "threshold", cfg: v1alpha1.Config{ICMP: v1alpha1.ICMP{NodePacketLossThreshold: "five"}}, err: true},
+		{name: "body regex", cfg: v1alpha1.Config{HTTP: v1alpha1.HTTP{Targets: []v1alpha1.HTTPTarget{{URL: "http://localhost", BodyRegex: "("}}}}, err: true},
+		{name: "threshold range", cfg: v1alpha1.Config{ICMP: v1alpha1.ICMP{NodePacketLossThreshold: "1.5"}}, err: true},
+		{name: "count", cfg: v1alpha1.Config{ICMP: v1alpha1.ICMP{Count: 1000}}, err: true},
+		{name: "additional target", cfg: v1alpha1.Config{ICMP: v1alpha1.ICMP{AdditionalTargets: []string{"not a host"}}}, err: true},
+		{name: "icmp socket", cfg: v1alpha1.Config{ICMP: v1alpha1.ICMP{Socket: "dgram"}}, err: true},
+		{name: "tcp target", cfg: v1alpha1.Config{TCP: v1alpha1.TCP{Targets: []string{"www.ionos.com"}}}, err: true},
+		{name: "http url", cfg: v1alpha1.Config{HTTP: v1alpha1.HTTP{Targets: []v1alpha1.HTTPTarget{{URL: "www.ionos.com"}}}}, err: true},
+		{name: "buckets", cfg: v1alpha1.Config{Histograms: v1alpha1.Histograms{Buckets: []string{"0.001", "fast"}}}, err: true},
+		{name: "buckets order", cfg: v1alpha1.Config{Histograms: v1alpha1.Histograms{Buckets: []string{"0.002", "0.001"}}}, err: true},
+		{name: "dns check type", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Checks: []v1alpha1.DNSCheck{{Name: "www.ionos.com", Type: "SOA"}}}}, err: true},
+		{name: "dns check name", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Checks: []v1alpha1.DNSCheck{{}}}}, err: true},
+		{name: "dns check answer", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Checks: []v1alpha1.DNSCheck{{Name: "www.ionos.com", Type: "AAAA", ExpectedAnswers: []string{"10.0.0.1"}}}}}, err: true},
+		{name: "dns check server", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Checks: []v1alpha1.DNSCheck{{Name: "www.ionos.com", Server: "10.96.0.10:dns"}}}}, err: true},
+		{name: "dns server scheme", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Server: "tls://1.1.1.1"}}, err: true},
+		{name: "dns server dot path", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Server: "dot://1.1.1.1/dns-query"}}, err: true},
+		{name: "dns check server doh", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{Checks: []v1alpha1.DNSCheck{{Name: "www.ionos.com", Server: "https:///dns-query"}}}}, err: true},
+		{name: "dns search name", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{SearchPath: v1alpha1.DNSSearchPath{Names: []string{"kubernetes default"}}}}, err: true},
+		{name: "dns search resolv.conf", cfg: v1alpha1.Config{DNS: v1alpha1.DNS{SearchPath: v1alpha1.DNSSearchPath{ResolvConf: "host"}}}, err: true},
+		{name: "mtu size", cfg: v1alpha1.Config{MTU: v1alpha1.MTU{Sizes: []int{1500, 20}}}, err: true},
+		{name: "mtu expected", cfg: v1alpha1.Config{MTU: v1alpha1.MTU{Expected: 70000}}, err: true},
+		{name: "mtu timeout", cfg: v1alpha1.Config{MTU: v1alpha1.MTU{Timeout: "soon"}}, err: true},
+		{name: "valid targets", cfg: v1alpha1.Config{
+			ICMP: v1alpha1.ICMP{AdditionalTargets: []string{"10.0.0.1", "www.ionos.com"}, Socket: v1alpha1.ICMPSocketDatagram},
+			TCP:  v1alpha1.TCP{Targets: []string{"www.ionos.com:443", "[::1]:80"}},
+			HTTP: v1alpha1.HTTP{Targets: []v1alpha1.HTTPTarget{{URL: "https://www.ionos.com"}}},
+			TLS:  v1alpha1.TLS{Targets: []v1alpha1.TLSTarget{{Address: "www.ionos.com:443"}}},
+			DNS: v1alpha1.DNS{Server: "dot://[2606:4700:4700::1111]", Checks: []v1alpha1.DNSCheck{
+				{Name: "_https._tcp.ionos.com", Type: v1alpha1.DNSRecordTypeSRV, Server: "10.96.0.10"},
+				{Name: "10.96.0.1", Type: v1alpha1.DNSRecordTypePTR},
+				{Name: "www.ionos.com", Server: "https://dns.google/dns-query"},
+				{Name: "www.ionos.com", Server: "dot://dns.google:853"},
+			}, SearchPath: v1alpha1.DNSSearchPath{
+				Names:      []string{"kubernetes.default", "www.ionos.com."},
+				ResolvConf: v1alpha1.DNSResolvConfNode,
+			}},
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Config().Validate(&tc.cfg)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfigDefault(t *testing.T) {
+	cfg := &v1alpha1.Config{
+		ICMP: v1alpha1.ICMP{Count: 3},
+		HTTP: v1alpha1.HTTP{Targets: []v1alpha1.HTTPTarget{{URL: "https://www.ionos.com"}}},
+	}
+
+	Config().Default(cfg)
+
+	assert.Equal(t, 3, cfg.ICMP.Count)
+	assert.Equal(t, "5s", cfg.ICMP.Timeout)
+	assert.Equal(t, "0.05", cfg.ICMP.NodePacketLossThreshold)
+	assert.Equal(t, "3s", cfg.DNS.Timeout)
+	assert.Equal(t, "5s", cfg.HTTP.Targets[0].Timeout)
+	assert.Equal(t, "1s", cfg.MTU.Timeout)
+	assert.Equal(t, 1500, cfg.MTU.Expected)
+	assert.NoError(t, Config().Validate(cfg))
+}
+
+func TestOwnIPs(t *testing.T) {
+	opts := &Opts{}
+	opts.Configure(
+		WithHostIP("10.0.0.1"),
+		WithHostIPs([]string{"10.0.0.1", "fd00::1"}),
+		WithPodIP("10.244.0.1"),
+		WithPodIPs([]string{"10.244.0.1", "fd00:244::1"}),
+	)
+
+	list := NewNodeList([]NodeLoader{func() ([]Target, error) {
+		return append(
+			Node{Name: "node-a", HostIPs: []string{"10.0.0.1", "fd00::1"}, PodIPs: []string{"10.244.0.1", "fd00:244::1"}}.Targets(),
+			Node{Name: "node-b", HostIPs: []string{"fd00::2"}}.Targets()...,
+		), nil
+	}}, FilterIP(opts.ownIPs()...))
+
+	targets, err := list.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Target{{IP: "fd00::2", Node: "node-b", Network: v1alpha1.NetworkHost, Family: v1alpha1.IPFamilyIPv6}}, targets)
+}
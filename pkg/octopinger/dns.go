@@ -3,10 +3,15 @@ package octopinger
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 type token struct{}
@@ -14,15 +19,22 @@ type token struct{}
 type dnsProbe struct {
 	opts *Opts
 
-	dnsError   *dnsError
-	dnsSuccess *dnsSuccess
+	dnsError     *dnsError
+	dnsSuccess   *dnsSuccess
+	dnsResults   *dnsResults
+	dnsEndpoints *dnsEndpoints
+	dnsUpstreams *dnsUpstreams
 
-	nodeName string
-	server   string
-	names    []string
+	name      string
+	nodeName  string
+	server    string
+	names     []string
+	endpoints bool
 
 	maxConcurrency int
 	resolver       *net.Resolver
+	upstream       *dnsUpstream
+	caFile         string
 
 	sem chan token
 	wg  sync.WaitGroup
@@ -39,9 +51,12 @@ func NewDNSProbe(nodeName, server string, names []string, opts ...Opt) *dnsProbe
 
 	d := new(dnsProbe)
 	d.opts = options
+	d.name = "dns"
 	d.nodeName = nodeName
 	d.server = server
 	d.names = names
+	d.endpoints = options.config != nil && options.config.DNS.Endpoints
+	d.caFile = filepath.Join(DNSCADir, DNSCAFile)
 	d.maxConcurrency = 100
 	d.sem = make(chan token, d.maxConcurrency)
 
@@ -55,16 +70,166 @@ func NewDNSProbe(nodeName, server string, names []string, opts ...Opt) *dnsProbe
 func (d *dnsProbe) Reset() {
 	d.dnsError = NewDNSError(d.nodeName)
 	d.dnsSuccess = NewDNSSuccess(d.nodeName)
+	d.dnsResults = NewDNSResults(d.name, d.nodeName)
+	d.dnsEndpoints = NewDNSEndpoints(d.nodeName)
+	d.dnsUpstreams = NewDNSUpstreams(d.nodeName)
 }
 
 // Collect ...
 func (d *dnsProbe) Collect(ch chan<- Metric) {
 	d.dnsError.Collect(ch)
 	d.dnsSuccess.Collect(ch)
+	d.dnsResults.Collect(ch)
+	d.dnsEndpoints.Collect(ch)
+	d.dnsUpstreams.Collect(ch)
+}
+
+// dnsServer is a resolver of the probe, which is dialing an endpoint of the cluster DNS Service in the endpoints mode.
+// Encrypted servers are queried by the upstream instead of the resolver.
+type dnsServer struct {
+	resolver *net.Resolver
+	upstream *dnsUpstream
+	address  string
+	endpoint *DNSEndpoint
+}
+
+type dnsUpstreamStat struct {
+	server    string
+	transport string
+	ipFamily  string
+	times     []float64
+	errors    []string
+}
+
+type dnsUpstreams struct {
+	values   []*dnsUpstreamStat
+	nodeName string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (d *dnsUpstreams) Write(monitor *Monitor) error {
+	for _, v := range d.values {
+		for _, class := range v.errors {
+			monitor.IncProbeDNSUpstreamError(d.nodeName, v.server, v.transport, v.ipFamily, class)
+		}
+
+		if len(v.times) == 0 {
+			continue
+		}
+
+		var total float64
+		for _, t := range v.times {
+			total += t
+
+			monitor.IncProbeDNSUpstreamSuccess(d.nodeName, v.server, v.transport, v.ipFamily)
+			monitor.ObserveProbeDNSUpstreamTime(d.nodeName, v.server, v.transport, v.ipFamily, t)
+		}
+
+		monitor.SetProbeDNSUpstreamTime(d.nodeName, v.server, v.transport, v.ipFamily, total/float64(len(v.times)))
+	}
+
+	return nil
+}
+
+// Collect ...
+func (d *dnsUpstreams) Collect(ch chan<- Metric) {
+	ch <- d
+}
+
+// NewDNSUpstreams ...
+func NewDNSUpstreams(nodeName string) *dnsUpstreams {
+	return &dnsUpstreams{
+		nodeName: nodeName,
+	}
+}
+
+type dnsEndpointStat struct {
+	endpoint   string
+	ip         string
+	node       string
+	ipFamily   string
+	successes  float64
+	errors     float64
+	lookupTime float64
+}
+
+type dnsEndpoints struct {
+	values   []*dnsEndpointStat
+	nodeName string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (d *dnsEndpoints) Write(monitor *Monitor) error {
+	monitor.ResetProbeDNSEndpoints(d.nodeName)
+
+	for _, v := range d.values {
+		monitor.SetProbeDNSEndpointSuccess(d.nodeName, v.endpoint, v.ip, v.node, v.ipFamily, v.successes)
+		monitor.SetProbeDNSEndpointError(d.nodeName, v.endpoint, v.ip, v.node, v.ipFamily, v.errors)
+
+		if v.successes > 0 {
+			monitor.SetProbeDNSEndpointTime(d.nodeName, v.endpoint, v.ip, v.node, v.ipFamily, v.lookupTime/v.successes)
+		}
+	}
+
+	return nil
+}
+
+// Collect ...
+func (d *dnsEndpoints) Collect(ch chan<- Metric) {
+	ch <- d
+}
+
+// NewDNSEndpoints ...
+func NewDNSEndpoints(nodeName string) *dnsEndpoints {
+	return &dnsEndpoints{
+		nodeName: nodeName,
+	}
+}
+
+type dnsResults struct {
+	values []Result
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (d *dnsResults) Write(monitor *Monitor) error {
+	for _, v := range d.values {
+		if v.Error == "" {
+			monitor.ObserveProbeDNSLookupTime(d.nodeName, v.IPFamily, v.RttMean)
+		}
+	}
+
+	monitor.SetProbeResults(d.nodeName, d.probeName, d.values)
+
+	return nil
+}
+
+// Collect ...
+func (d *dnsResults) Collect(ch chan<- Metric) {
+	ch <- d
+}
+
+// NewDNSResults ...
+func NewDNSResults(probeName, nodeName string) *dnsResults {
+	return &dnsResults{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
 }
 
 type dnsSuccess struct {
-	value    float64
+	values   map[v1alpha1.IPFamily]float64
 	nodeName string
 
 	Metric
@@ -73,7 +238,9 @@ type dnsSuccess struct {
 
 // Write ...
 func (d *dnsSuccess) Write(monitor *Monitor) error {
-	monitor.SetProbeDNSSuccess(d.nodeName, d.value)
+	for family, value := range d.values {
+		monitor.SetProbeDNSSuccess(d.nodeName, string(family), value)
+	}
 
 	return nil
 }
@@ -86,12 +253,13 @@ func (d *dnsSuccess) Collect(ch chan<- Metric) {
 // NewDNSSuccess ...
 func NewDNSSuccess(nodeName string) *dnsSuccess {
 	return &dnsSuccess{
+		values:   make(map[v1alpha1.IPFamily]float64),
 		nodeName: nodeName,
 	}
 }
 
 type dnsError struct {
-	value    float64
+	values   map[v1alpha1.IPFamily]float64
 	nodeName string
 
 	Metric
@@ -100,7 +268,9 @@ type dnsError struct {
 
 // Write ...
 func (d *dnsError) Write(monitor *Monitor) error {
-	monitor.SetProbeDNSError(d.nodeName, d.value)
+	for family, value := range d.values {
+		monitor.SetProbeDNSError(d.nodeName, string(family), value)
+	}
 
 	return nil
 }
@@ -113,99 +283,326 @@ func (d *dnsError) Collect(ch chan<- Metric) {
 // NewDNSError ...
 func NewDNSError(nodeName string) *dnsError {
 	return &dnsError{
+		values:   make(map[v1alpha1.IPFamily]float64),
 		nodeName: nodeName,
 	}
 }
 
 // IncSuccess ...
-func (d *dnsProbe) IncSuccess() {
+func (d *dnsProbe) IncSuccess(family v1alpha1.IPFamily) {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 
-	d.dnsSuccess.value += 1
+	d.dnsSuccess.values[family] += 1
 }
 
 // IncError ...
-func (d *dnsProbe) IncError() {
+func (d *dnsProbe) IncError(family v1alpha1.IPFamily) {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 
-	d.dnsError.value += 1
+	d.dnsError.values[family] += 1
+}
+
+// AddEndpointResult ...
+func (d *dnsProbe) AddEndpointResult(server dnsServer, family v1alpha1.IPFamily, rtt time.Duration, err error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	var stat *dnsEndpointStat
+	for _, v := range d.dnsEndpoints.values {
+		if v.ip == server.address && v.ipFamily == string(family) {
+			stat = v
+			break
+		}
+	}
+
+	if stat == nil {
+		stat = &dnsEndpointStat{endpoint: server.endpoint.Name, ip: server.address, node: server.endpoint.Node, ipFamily: string(family)}
+		d.dnsEndpoints.values = append(d.dnsEndpoints.values, stat)
+	}
+
+	if err != nil {
+		stat.errors++
+		return
+	}
+
+	stat.successes++
+	stat.lookupTime += float64(rtt.Microseconds())
+}
+
+// AddUpstreamResult ...
+func (d *dnsProbe) AddUpstreamResult(upstream *dnsUpstream, family v1alpha1.IPFamily, rtt time.Duration, err error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	var stat *dnsUpstreamStat
+	for _, v := range d.dnsUpstreams.values {
+		if v.server == upstream.server && v.ipFamily == string(family) {
+			stat = v
+			break
+		}
+	}
+
+	if stat == nil {
+		stat = &dnsUpstreamStat{server: upstream.server, transport: upstream.transport, ipFamily: string(family)}
+		d.dnsUpstreams.values = append(d.dnsUpstreams.values, stat)
+	}
+
+	if err != nil {
+		stat.errors = append(stat.errors, DNSErrorClass(err))
+		return
+	}
+
+	stat.times = append(stat.times, float64(rtt.Microseconds()))
+}
+
+// AddResult ...
+func (d *dnsProbe) AddResult(host string, family v1alpha1.IPFamily, server string, rtt time.Duration, err error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	result := Result{
+		Target:   host,
+		Server:   server,
+		IPFamily: string(family),
+		RttMin:   float64(rtt.Microseconds()),
+		RttMean:  float64(rtt.Microseconds()),
+		RttMax:   float64(rtt.Microseconds()),
+	}
+
+	if err != nil {
+		result.Loss = 1
+		result.Error = err.Error()
+	}
+
+	d.dnsResults.values = append(d.dnsResults.values, result)
+}
+
+// families returns the families to resolve, which are all families by default.
+func (d *dnsProbe) families() []v1alpha1.IPFamily {
+	if len(d.opts.ipFamilies) == 0 {
+		return []v1alpha1.IPFamily{""}
+	}
+
+	return d.opts.ipFamilies
 }
 
 // Do ...
 func (d *dnsProbe) Do(ctx context.Context, metrics Gatherer) func() error {
 	return func() error {
-		ticker := time.NewTicker(1 * time.Second)
+		err := d.configure(d.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(d.opts.interval)
 		defer ticker.Stop()
 
+		loader := DNSEndpointsLoader(d.opts.configPath)
+
 		for {
 			select {
 			case <-ctx.Done():
+				return nil
 			case <-ticker.C:
-				d.do(ctx, d.names...)
+				servers := []dnsServer{{resolver: d.resolver, upstream: d.upstream, address: d.server}}
+
+				if d.endpoints {
+					endpoints, err := loader()
+					if err != nil {
+						return err
+					}
+
+					servers = d.endpointServers(endpoints)
+				}
+
+				d.do(ctx, servers, d.names...)
 
 				metrics.Gather(d)
-				ticker.Reset(1 * time.Second)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+// endpointServers returns a resolver for each IP of the endpoints in the probed families.
+func (d *dnsProbe) endpointServers(endpoints []DNSEndpoint) []dnsServer {
+	servers := make([]dnsServer, 0, len(endpoints))
+
+	for i := range endpoints {
+		e := &endpoints[i]
+
+		port := e.Port
+		if port == 0 {
+			port = 53
+		}
 
+		for _, ip := range e.IPs {
+			if len(d.opts.ipFamilies) > 0 && !slices.Contains(d.opts.ipFamilies, IPFamilyOf(ip)) {
 				continue
 			}
+
+			address := net.JoinHostPort(ip, strconv.Itoa(port))
+			servers = append(servers, dnsServer{resolver: d.newResolver(address), address: address, endpoint: e})
 		}
 	}
+
+	return servers
 }
 
-func (d *dnsProbe) do(ctx context.Context, hosts ...string) {
+func (d *dnsProbe) do(ctx context.Context, servers []dnsServer, hosts ...string) {
 	d.Reset()
 
-	for _, host := range hosts {
-		host := host
+	for _, family := range d.families() {
+		d.dnsSuccess.values[family] = 0
+		d.dnsError.values[family] = 0
 
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
+		for _, server := range servers {
+			for _, host := range hosts {
+				host := host
+				family := family
+				server := server
 
-			d.sem <- token{}
+				d.wg.Add(1)
+				go func() {
+					defer d.wg.Done()
 
-			err := d.resolve(ctx, host)
-			if err != nil {
-				d.IncError()
-			} else {
-				d.IncSuccess()
-			}
+					d.sem <- token{}
+
+					var err error
+
+					start := time.Now()
+					if server.upstream != nil {
+						err = d.exchange(ctx, server.upstream, host, family)
+					} else {
+						err = d.resolve(ctx, server.resolver, host, family)
+					}
+					rtt := time.Since(start)
 
-			<-d.sem
-		}()
+					d.AddResult(host, family, server.address, rtt, err)
+
+					if server.upstream != nil {
+						d.AddUpstreamResult(server.upstream, family, rtt, err)
+					}
+
+					if server.endpoint != nil {
+						d.AddEndpointResult(server, family, rtt, err)
+					}
+
+					if err != nil {
+						d.IncError(family)
+					} else {
+						d.IncSuccess(family)
+					}
+
+					<-d.sem
+				}()
+			}
+		}
 	}
 
 	d.wg.Wait()
 }
 
-func (d *dnsProbe) resolve(ctx context.Context, host string) error {
+// resolve is looking up the A records of a host for IPv4, or the AAAA records for IPv6.
+func (d *dnsProbe) resolve(ctx context.Context, resolver *net.Resolver, host string, family v1alpha1.IPFamily) error {
 	ctx, cancel := context.WithTimeout(ctx, d.opts.timeout)
 	defer cancel()
 
-	ips, err := d.resolver.LookupHost(ctx, host)
+	ips, err := resolver.LookupIP(ctx, FamilyNetwork("ip", family), host)
+	if err != nil {
+		return err
+	}
+
 	if len(ips) == 0 {
 		return ErrResolveHost
 	}
 
+	return nil
+}
+
+// exchange is querying the A records of a host for IPv4, the AAAA records for IPv6,
+// or both without a family, at an encrypted server.
+func (d *dnsProbe) exchange(ctx context.Context, upstream *dnsUpstream, host string, family v1alpha1.IPFamily) error {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.timeout)
+	defer cancel()
+
+	qtypes := []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	switch family {
+	case v1alpha1.IPFamilyIPv4:
+		qtypes = qtypes[:1]
+	case v1alpha1.IPFamilyIPv6:
+		qtypes = qtypes[1:]
+	}
+
+	answers := 0
+	for _, qtype := range qtypes {
+		query, err := newDNSQuery(host, qtype)
+		if err != nil {
+			return err
+		}
+
+		resp, err := upstream.exchange(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		if resp.RCode != dnsmessage.RCodeSuccess {
+			return &DNSRCodeError{RCode: resp.RCode}
+		}
+
+		answers += len(dnsAnswers(resp, qtype))
+	}
+
+	if answers == 0 {
+		return ErrResolveHost
+	}
+
+	return nil
+}
+
+// configure is creating the upstream of an encrypted server.
+func (d *dnsProbe) configure(c *v1alpha1.Config) error {
+	transport, _, err := parseDNSServer(d.server)
+	if err != nil || transport == DNSTransportDNS {
+		return err
+	}
+
+	config, err := newDNSTLSConfig(c.DNS.TLS, d.caFile)
+	if err != nil {
+		return err
+	}
+
+	d.upstream, err = newDNSUpstream(d.server, config)
+
 	return err
 }
 
 func (dp *dnsProbe) configureResolver() {
+	dp.resolver = dp.newResolver(dp.server)
+}
+
+// newResolver returns a resolver that is dialing the server,
+// or the name servers of the pod if the server is empty.
+func (dp *dnsProbe) newResolver(server string) *net.Resolver {
 	r := &net.Resolver{
 		PreferGo: true,
 	}
 
-	if dp.server != "" {
-		r.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+	if server != "" {
+		address := dnsServerAddress(server)
+
+		r.Dial = func(ctx context.Context, network, _ string) (net.Conn, error) {
 			d := net.Dialer{
 				Timeout: dp.opts.timeout,
 			}
 
-			return d.DialContext(ctx, network, fmt.Sprintf("%s:53", dp.server))
+			return d.DialContext(ctx, network, address)
 		}
 	}
 
-	dp.resolver = r
+	return r
 }
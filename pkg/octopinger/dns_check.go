@@ -0,0 +1,424 @@
+package octopinger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DNSErrorNXDomain is the error class of a name that does not exist.
+	DNSErrorNXDomain = "nxdomain"
+	// DNSErrorServFail is the error class of a server that failed to answer, e.g. a broken forwarder.
+	DNSErrorServFail = "servfail"
+	// DNSErrorRefused is the error class of a server that refused to answer.
+	DNSErrorRefused = "refused"
+	// DNSErrorRCode is the error class of all other response codes.
+	DNSErrorRCode = "rcode"
+	// DNSErrorTimeout is the error class of a query without a response.
+	DNSErrorTimeout = "timeout"
+	// DNSErrorNoAnswer is the error class of a response without answers.
+	DNSErrorNoAnswer = "no_answer"
+	// DNSErrorMismatch is the error class of a response with unexpected answers.
+	DNSErrorMismatch = "mismatch"
+	// DNSErrorTLS is the error class of a failed handshake with an encrypted server.
+	DNSErrorTLS = "tls"
+	// DNSErrorHTTP is the error class of an unexpected status code of a DNS-over-HTTPS server.
+	DNSErrorHTTP = "http"
+	// DNSErrorUnknown is the error class of all other errors.
+	DNSErrorUnknown = "unknown"
+)
+
+var (
+	// ErrDNSNoAnswer ...
+	ErrDNSNoAnswer = errors.New("no answer")
+	// ErrDNSAnswerMismatch ...
+	ErrDNSAnswerMismatch = errors.New("unexpected answers")
+	// ErrDNSNoServer ...
+	ErrDNSNoServer = errors.New("no dns server")
+)
+
+// DNSRCodeError is the error of a response with another code than NOERROR.
+type DNSRCodeError struct {
+	RCode dnsmessage.RCode
+}
+
+// Error ...
+func (e *DNSRCodeError) Error() string {
+	switch e.RCode {
+	case dnsmessage.RCodeFormatError:
+		return "FORMERR"
+	case dnsmessage.RCodeServerFailure:
+		return "SERVFAIL"
+	case dnsmessage.RCodeNameError:
+		return "NXDOMAIN"
+	case dnsmessage.RCodeNotImplemented:
+		return "NOTIMP"
+	case dnsmessage.RCodeRefused:
+		return "REFUSED"
+	}
+
+	return fmt.Sprintf("RCODE%d", e.RCode)
+}
+
+// DNSErrorClass returns the class of a DNS check error.
+func DNSErrorClass(err error) string {
+	var rcodeErr *DNSRCodeError
+
+	switch {
+	case errors.As(err, &rcodeErr):
+		switch rcodeErr.RCode {
+		case dnsmessage.RCodeNameError:
+			return DNSErrorNXDomain
+		case dnsmessage.RCodeServerFailure:
+			return DNSErrorServFail
+		case dnsmessage.RCodeRefused:
+			return DNSErrorRefused
+		}
+
+		return DNSErrorRCode
+	case errors.Is(err, ErrDNSNoAnswer):
+		return DNSErrorNoAnswer
+	case errors.Is(err, ErrDNSAnswerMismatch):
+		return DNSErrorMismatch
+	case errors.Is(err, ErrHTTPStatus):
+		return DNSErrorHTTP
+	case isTLSError(err):
+		return DNSErrorTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return DNSErrorTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return DNSErrorTimeout
+	}
+
+	return DNSErrorUnknown
+}
+
+type dnsCheckStat struct {
+	name       string
+	recordType string
+	server     string
+	time       float64
+	answers    int
+	err        error
+}
+
+type dnsCheckStats struct {
+	values []dnsCheckStat
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (d *dnsCheckStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeDNSChecks(d.nodeName)
+
+	results := make([]Result, 0, len(d.values))
+
+	for _, v := range d.values {
+		result := Result{
+			Target:     v.name,
+			RecordType: v.recordType,
+			Server:     v.server,
+		}
+
+		monitor.SetProbeDNSCheckAnswers(d.nodeName, v.name, v.recordType, v.server, float64(v.answers))
+
+		if v.err != nil {
+			monitor.IncProbeDNSCheckError(d.nodeName, v.name, v.recordType, v.server, DNSErrorClass(v.err))
+
+			result.Loss = 1
+			result.Error = v.err.Error()
+		} else {
+			monitor.IncProbeDNSCheckSuccess(d.nodeName, v.name, v.recordType, v.server)
+			monitor.SetProbeDNSCheckTime(d.nodeName, v.name, v.recordType, v.server, v.time)
+			monitor.ObserveProbeDNSCheckTime(d.nodeName, v.name, v.recordType, v.server, v.time)
+
+			result.RttMin = v.time
+			result.RttMean = v.time
+			result.RttMax = v.time
+		}
+
+		results = append(results, result)
+	}
+
+	monitor.SetProbeResults(d.nodeName, d.probeName, results)
+
+	return nil
+}
+
+// Collect ...
+func (d *dnsCheckStats) Collect(ch chan<- Metric) {
+	ch <- d
+}
+
+// NewDNSCheckStats ...
+func NewDNSCheckStats(probeName, nodeName string) *dnsCheckStats {
+	return &dnsCheckStats{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+type dnsCheckProbe struct {
+	opts *Opts
+
+	dnsCheckStats *dnsCheckStats
+
+	name       string
+	nodeName   string
+	server     string
+	checks     []v1alpha1.DNSCheck
+	resolvConf string
+	caFile     string
+	upstreams  map[string]*dnsUpstream
+
+	maxConcurrency int
+
+	sem chan token
+	wg  sync.WaitGroup
+	mux sync.Mutex
+}
+
+// NewDNSCheckProbe ...
+func NewDNSCheckProbe(nodeName, server string, checks []v1alpha1.DNSCheck, opts ...Opt) *dnsCheckProbe {
+	options := new(Opts)
+	options.Configure(opts...)
+
+	d := new(dnsCheckProbe)
+	d.opts = options
+	d.name = "dns_check"
+	d.nodeName = nodeName
+	d.server = server
+	d.checks = checks
+	d.resolvConf = defaultResolvConf
+	d.caFile = filepath.Join(DNSCADir, DNSCAFile)
+	d.maxConcurrency = 100
+	d.sem = make(chan token, d.maxConcurrency)
+
+	if d.opts.timeout == 0 {
+		d.opts.timeout = defaultDNSTimeout
+	}
+
+	d.Reset()
+
+	return d
+}
+
+func (d *dnsCheckProbe) configure(c *v1alpha1.Config) error {
+	config, err := newDNSTLSConfig(c.DNS.TLS, d.caFile)
+	if err != nil {
+		return err
+	}
+
+	upstreams := make(map[string]*dnsUpstream)
+
+	servers := []string{d.server}
+	for _, check := range d.checks {
+		servers = append(servers, check.Server)
+	}
+
+	for _, server := range servers {
+		if server == "" {
+			continue
+		}
+
+		u, err := newDNSUpstream(server, config)
+		if err != nil {
+			return err
+		}
+
+		upstreams[server] = u
+	}
+
+	d.upstreams = upstreams
+
+	return nil
+}
+
+// upstream returns the configured upstream of a server, or a new upstream for the name servers of the pod.
+func (d *dnsCheckProbe) upstream(server string) (*dnsUpstream, error) {
+	if u, ok := d.upstreams[server]; ok {
+		return u, nil
+	}
+
+	return newDNSUpstream(server, nil)
+}
+
+// Reset ...
+func (d *dnsCheckProbe) Reset() {
+	d.dnsCheckStats = NewDNSCheckStats(d.name, d.nodeName)
+}
+
+// Collect ...
+func (d *dnsCheckProbe) Collect(ch chan<- Metric) {
+	d.dnsCheckStats.Collect(ch)
+}
+
+// AddStat ...
+func (d *dnsCheckProbe) AddStat(stat dnsCheckStat) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.dnsCheckStats.values = append(d.dnsCheckStats.values, stat)
+}
+
+// Do ...
+func (d *dnsCheckProbe) Do(ctx context.Context, metrics Gatherer) func() error {
+	return func() error {
+		err := d.configure(d.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(d.opts.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				d.do(ctx)
+
+				metrics.Gather(d)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+func (d *dnsCheckProbe) do(ctx context.Context) {
+	d.Reset()
+
+	// the resolv.conf is read every round, to follow changes of the cluster DNS
+	server := d.server
+	if server == "" {
+		conf, err := loadResolvConf(d.resolvConf)
+		if err == nil && len(conf.servers) > 0 {
+			server = conf.servers[0]
+		}
+	}
+
+	for _, check := range d.checks {
+		check := check
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+
+			d.sem <- token{}
+			d.AddStat(d.check(ctx, server, check))
+			<-d.sem
+		}()
+	}
+
+	d.wg.Wait()
+}
+
+// check is querying the records of a check and validates the answers.
+func (d *dnsCheckProbe) check(ctx context.Context, server string, check v1alpha1.DNSCheck) dnsCheckStat {
+	recordType := check.Type
+	if recordType == "" {
+		recordType = v1alpha1.DNSRecordTypeA
+	}
+
+	if check.Server != "" {
+		server = check.Server
+	}
+
+	stat := dnsCheckStat{
+		name:       check.Name,
+		recordType: string(recordType),
+		server:     server,
+	}
+
+	if server == "" {
+		stat.err = ErrDNSNoServer
+		return stat
+	}
+
+	qtype, ok := dnsRecordTypes[recordType]
+	if !ok {
+		stat.err = fmt.Errorf("invalid record type: %s", recordType)
+		return stat
+	}
+
+	upstream, err := d.upstream(server)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+
+	query, err := newDNSQuery(check.Name, qtype)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.opts.timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := upstream.exchange(ctx, query)
+	stat.time = float64(time.Since(start).Microseconds())
+
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		stat.err = &DNSRCodeError{RCode: resp.RCode}
+		return stat
+	}
+
+	answers := dnsAnswers(resp, qtype)
+	stat.answers = len(answers)
+	stat.err = validateAnswers(check, qtype, answers)
+
+	return stat
+}
+
+// validateAnswers is checking the answers against the expected answers and count of a check.
+func validateAnswers(check v1alpha1.DNSCheck, qtype dnsmessage.Type, answers []string) error {
+	if len(answers) == 0 {
+		return ErrDNSNoAnswer
+	}
+
+	if check.ExpectedAnswerCount > 0 && len(answers) != check.ExpectedAnswerCount {
+		return fmt.Errorf("%w: %d answers instead of %d", ErrDNSAnswerMismatch, len(answers), check.ExpectedAnswerCount)
+	}
+
+	missing := make([]string, 0)
+	for _, expected := range check.ExpectedAnswers {
+		if !slices.Contains(answers, normalizeAnswer(qtype, expected)) {
+			missing = append(missing, expected)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s in %s", ErrDNSAnswerMismatch, strings.Join(missing, ", "), strings.Join(answers, ", "))
+	}
+
+	return nil
+}
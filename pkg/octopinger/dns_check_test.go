@@ -0,0 +1,314 @@
+package octopinger
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// dnsHandler is answering a question with a response code and the answers.
+type dnsHandler func(q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource)
+
+// answerDNS returns the packed response of the handler to a packed query.
+func answerDNS(handler dnsHandler, buf []byte) ([]byte, error) {
+	var query dnsmessage.Message
+	if err := query.Unpack(buf); err != nil {
+		return nil, err
+	}
+
+	if len(query.Questions) != 1 {
+		return nil, ErrDNSResponse
+	}
+
+	rcode, answers := handler(query.Questions[0])
+
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: rcode},
+		Questions: query.Questions,
+		Answers:   answers,
+	}
+
+	return resp.Pack()
+}
+
+// serveDNS is answering queries on a local UDP port with the handler.
+func serveDNS(t *testing.T, handler dnsHandler) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, maxDNSMessageSize)
+
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			b, err := answerDNS(handler, buf[:n])
+			if err != nil {
+				continue
+			}
+
+			_, _ = conn.WriteTo(b, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// serveEncryptedDNS is answering queries over DNS-over-HTTPS at "/dns-query" and over DNS-over-TLS.
+// It returns both servers and the path of a CA file, which is verifying their certificate.
+func serveEncryptedDNS(t *testing.T, handler dnsHandler) (string, string, string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /dns-query", func(w http.ResponseWriter, r *http.Request) {
+		buf, err := io.ReadAll(r.Body)
+		if err != nil || r.Header.Get("Content-Type") != dnsMessageContentType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		b, err := answerDNS(handler, buf)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", dnsMessageContentType)
+		_, _ = w.Write(b)
+	})
+
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() { _ = conn.Close() }()
+
+				buf, err := readDNSMessage(conn, true)
+				if err != nil {
+					return
+				}
+
+				b, err := answerDNS(handler, buf)
+				if err != nil {
+					return
+				}
+
+				_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(b))), b...))
+			}()
+		}
+	}()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	return "dot://" + l.Addr().String(), srv.URL + "/dns-query", caFile
+}
+
+func resource(q dnsmessage.Question, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 30},
+		Body:   body,
+	}
+}
+
+func testZone(q dnsmessage.Question) (dnsmessage.RCode, []dnsmessage.Resource) {
+	switch q.Name.String() {
+	case "kubernetes.default.svc.cluster.local.":
+		return dnsmessage.RCodeSuccess, []dnsmessage.Resource{
+			resource(q, &dnsmessage.AResource{A: [4]byte{10, 96, 0, 1}}),
+		}
+	case "www.ionos.com.":
+		if q.Type == dnsmessage.TypeMX {
+			return dnsmessage.RCodeSuccess, []dnsmessage.Resource{
+				resource(q, &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("Mail.ionos.com.")}),
+			}
+		}
+
+		return dnsmessage.RCodeSuccess, []dnsmessage.Resource{
+			resource(q, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}),
+			resource(q, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 2}}),
+		}
+	case "1.0.96.10.in-addr.arpa.":
+		return dnsmessage.RCodeSuccess, []dnsmessage.Resource{
+			resource(q, &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("kubernetes.default.svc.cluster.local.")}),
+		}
+	case "empty.ionos.com.":
+		return dnsmessage.RCodeSuccess, nil
+	case "forward.ionos.com.":
+		return dnsmessage.RCodeServerFailure, nil
+	}
+
+	return dnsmessage.RCodeNameError, nil
+}
+
+func TestDNSCheckProbe(t *testing.T) {
+	server := serveDNS(t, testZone)
+
+	tests := []struct {
+		name    string
+		check   v1alpha1.DNSCheck
+		answers int
+		class   string
+	}{
+		{name: "a", check: v1alpha1.DNSCheck{Name: "kubernetes.default.svc.cluster.local"}, answers: 1},
+		{name: "expected answers", check: v1alpha1.DNSCheck{Name: "www.ionos.com", ExpectedAnswers: []string{"10.0.0.2"}, ExpectedAnswerCount: 2}, answers: 2},
+		{name: "missing answer", check: v1alpha1.DNSCheck{Name: "www.ionos.com", ExpectedAnswers: []string{"10.0.0.3"}}, answers: 2, class: DNSErrorMismatch},
+		{name: "answer count", check: v1alpha1.DNSCheck{Name: "www.ionos.com", ExpectedAnswerCount: 3}, answers: 2, class: DNSErrorMismatch},
+		{name: "mx", check: v1alpha1.DNSCheck{Name: "www.ionos.com", Type: v1alpha1.DNSRecordTypeMX, ExpectedAnswers: []string{"10 mail.ionos.com."}}, answers: 1},
+		{name: "ptr", check: v1alpha1.DNSCheck{Name: "10.96.0.1", Type: v1alpha1.DNSRecordTypePTR, ExpectedAnswers: []string{"kubernetes.default.svc.cluster.local"}}, answers: 1},
+		{name: "no answer", check: v1alpha1.DNSCheck{Name: "empty.ionos.com"}, class: DNSErrorNoAnswer},
+		{name: "nxdomain", check: v1alpha1.DNSCheck{Name: "monalisa.ionos.com"}, class: DNSErrorNXDomain},
+		{name: "servfail", check: v1alpha1.DNSCheck{Name: "forward.ionos.com"}, class: DNSErrorServFail},
+	}
+
+	p := NewDNSCheckProbe("monalisa", server, nil)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stat := p.check(context.Background(), server, tc.check)
+
+			assert.Equal(t, tc.answers, stat.answers)
+
+			if tc.class == "" {
+				assert.NoError(t, stat.err)
+			} else {
+				assert.Equal(t, tc.class, DNSErrorClass(stat.err))
+			}
+		})
+	}
+}
+
+func TestDNSCheckProbeServer(t *testing.T) {
+	server := serveDNS(t, testZone)
+
+	dir := t.TempDir()
+	resolvConf := filepath.Join(dir, "resolv.conf")
+	assert.NoError(t, os.WriteFile(resolvConf, []byte("search default.svc.cluster.local\nnameserver "+server+"\n"), 0o600))
+
+	p := NewDNSCheckProbe("monalisa", "", []v1alpha1.DNSCheck{
+		{Name: "kubernetes.default.svc.cluster.local"},
+		{Name: "kubernetes.default.svc.cluster.local", Server: "127.0.0.1:1"},
+	})
+	p.resolvConf = resolvConf
+	p.do(context.Background())
+
+	assert.Len(t, p.dnsCheckStats.values, 2)
+
+	for _, v := range p.dnsCheckStats.values {
+		if v.server == server {
+			assert.NoError(t, v.err)
+		} else {
+			assert.Equal(t, "127.0.0.1:1", v.server)
+			assert.Error(t, v.err)
+		}
+	}
+}
+
+func TestDNSCheckProbeEncrypted(t *testing.T) {
+	dot, doh, caFile := serveEncryptedDNS(t, testZone)
+	dnsCA := &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "dns-ca"}, Key: "ca.crt"}
+
+	tests := []struct {
+		name   string
+		server string
+		tls    v1alpha1.DNSTLS
+		class  string
+	}{
+		{name: "dot", server: dot, tls: v1alpha1.DNSTLS{CAConfigMap: dnsCA}},
+		{name: "doh", server: doh, tls: v1alpha1.DNSTLS{CAConfigMap: dnsCA}},
+		{name: "insecure", server: dot, tls: v1alpha1.DNSTLS{InsecureSkipVerify: true}},
+		{name: "untrusted", server: doh, class: DNSErrorTLS},
+		{name: "server name", server: dot, tls: v1alpha1.DNSTLS{CAConfigMap: dnsCA, ServerName: "dns.ionos.com"}, class: DNSErrorTLS},
+		{name: "status", server: doh + "/404", tls: v1alpha1.DNSTLS{CAConfigMap: dnsCA}, class: DNSErrorHTTP},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			check := v1alpha1.DNSCheck{Name: "kubernetes.default.svc.cluster.local", Server: tc.server}
+
+			p := NewDNSCheckProbe("monalisa", "", []v1alpha1.DNSCheck{check}, WithTimeout(time.Second))
+			p.caFile = caFile
+			assert.NoError(t, p.configure(&v1alpha1.Config{DNS: v1alpha1.DNS{TLS: tc.tls}}))
+
+			stat := p.check(context.Background(), "", check)
+			assert.Equal(t, tc.server, stat.server)
+
+			if tc.class == "" {
+				assert.NoError(t, stat.err)
+				assert.Equal(t, 1, stat.answers)
+			} else {
+				assert.Equal(t, tc.class, DNSErrorClass(stat.err))
+			}
+		})
+	}
+}
+
+func TestParseDNSServer(t *testing.T) {
+	tests := []struct {
+		server    string
+		transport string
+		address   string
+		err       bool
+	}{
+		{server: "10.96.0.10", transport: DNSTransportDNS, address: "10.96.0.10:53"},
+		{server: "dot://1.1.1.1", transport: DNSTransportDoT, address: "1.1.1.1:853"},
+		{server: "dot://[2606:4700:4700::1111]:8853", transport: DNSTransportDoT, address: "[2606:4700:4700::1111]:8853"},
+		{server: "https://dns.google/dns-query", transport: DNSTransportDoH, address: "https://dns.google/dns-query"},
+		{server: "dot://dns.google/dns-query", err: true},
+		{server: "tls://dns.google", err: true},
+		{server: "https:///dns-query", err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.server, func(t *testing.T) {
+			transport, address, err := parseDNSServer(tc.server)
+			if tc.err {
+				assert.ErrorIs(t, err, ErrDNSServer)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.transport, transport)
+			assert.Equal(t, tc.address, address)
+		})
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	assert.Equal(t, "1.0.96.10.in-addr.arpa.", reverseName(netip.MustParseAddr("10.96.0.1")))
+	assert.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", reverseName(netip.MustParseAddr("2001:db8::1")))
+}
+
+func TestDNSServerAddress(t *testing.T) {
+	assert.Equal(t, "10.96.0.10:53", dnsServerAddress("10.96.0.10"))
+	assert.Equal(t, "10.96.0.10:5353", dnsServerAddress("10.96.0.10:5353"))
+	assert.Equal(t, "[fd00::10]:53", dnsServerAddress("fd00::10"))
+}
@@ -0,0 +1,360 @@
+package octopinger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// NodeResolvConf is the path of the resolv.conf of the node in the Octopinger pod.
+	NodeResolvConf = "/host/etc/resolv.conf"
+	// NodeResolvConfHostPath is the path of the resolv.conf on the node.
+	NodeResolvConfHostPath = "/etc/resolv.conf"
+	// NodeResolvedDir is the path of the runtime directory of systemd-resolved of the node in the Octopinger pod.
+	NodeResolvedDir = "/host/run/systemd/resolve"
+	// NodeResolvedDirHostPath is the path of the runtime directory of systemd-resolved on the node.
+	NodeResolvedDirHostPath = "/run/systemd/resolve"
+
+	// absoluteSearchDomain is the search domain label of the queries of the name itself.
+	absoluteSearchDomain = "."
+)
+
+type dnsSearchQuery struct {
+	domain string
+	time   float64
+}
+
+type dnsSearchStat struct {
+	name    string
+	server  string
+	queries []dnsSearchQuery
+	time    float64
+	err     error
+}
+
+type dnsSearchStats struct {
+	values []dnsSearchStat
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (d *dnsSearchStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeDNSSearch(d.nodeName)
+
+	results := make([]Result, 0, len(d.values))
+	domains := make(map[string][]float64)
+
+	for _, v := range d.values {
+		result := Result{
+			Target:  v.name,
+			Server:  v.server,
+			Queries: len(v.queries),
+			RttMin:  v.time,
+			RttMean: v.time,
+			RttMax:  v.time,
+		}
+
+		for _, q := range v.queries {
+			domains[q.domain] = append(domains[q.domain], q.time)
+		}
+
+		monitor.SetProbeDNSSearchQueries(d.nodeName, v.name, float64(len(v.queries)))
+		monitor.SetProbeDNSSearchTime(d.nodeName, v.name, v.time)
+
+		if v.err != nil {
+			monitor.IncProbeDNSSearchError(d.nodeName, v.name, DNSErrorClass(v.err))
+
+			result.Loss = 1
+			result.Error = v.err.Error()
+		} else {
+			monitor.IncProbeDNSSearchSuccess(d.nodeName, v.name)
+			monitor.ObserveProbeDNSSearchTime(d.nodeName, v.name, v.time)
+		}
+
+		results = append(results, result)
+	}
+
+	for domain, times := range domains {
+		var total float64
+		for _, t := range times {
+			total += t
+		}
+
+		monitor.SetProbeDNSSearchDomainTime(d.nodeName, domain, total/float64(len(times)))
+	}
+
+	monitor.SetProbeResults(d.nodeName, d.probeName, results)
+
+	return nil
+}
+
+// Collect ...
+func (d *dnsSearchStats) Collect(ch chan<- Metric) {
+	ch <- d
+}
+
+// NewDNSSearchStats ...
+func NewDNSSearchStats(probeName, nodeName string) *dnsSearchStats {
+	return &dnsSearchStats{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+type dnsSearchProbe struct {
+	opts *Opts
+
+	dnsSearchStats *dnsSearchStats
+
+	name       string
+	nodeName   string
+	names      []string
+	resolvConf string
+	// resolvedDir is the runtime directory of systemd-resolved, whose resolv.conf is preferred.
+	resolvedDir string
+
+	maxConcurrency int
+
+	sem chan token
+	wg  sync.WaitGroup
+	mux sync.Mutex
+}
+
+// NewDNSSearchProbe ...
+func NewDNSSearchProbe(nodeName string, names []string, opts ...Opt) *dnsSearchProbe {
+	options := new(Opts)
+	options.Configure(opts...)
+
+	d := new(dnsSearchProbe)
+	d.opts = options
+	d.name = "dns_search"
+	d.nodeName = nodeName
+	d.names = names
+	d.resolvConf = defaultResolvConf
+	d.maxConcurrency = 100
+	d.sem = make(chan token, d.maxConcurrency)
+
+	if d.opts.timeout == 0 {
+		d.opts.timeout = defaultDNSTimeout
+	}
+
+	d.Reset()
+
+	return d
+}
+
+func (d *dnsSearchProbe) configure(c *v1alpha1.Config) error {
+	if c.DNS.SearchPath.ResolvConf == v1alpha1.DNSResolvConfNode {
+		d.resolvConf = NodeResolvConf
+		d.resolvedDir = NodeResolvedDir
+	}
+
+	return nil
+}
+
+// Reset ...
+func (d *dnsSearchProbe) Reset() {
+	d.dnsSearchStats = NewDNSSearchStats(d.name, d.nodeName)
+}
+
+// Collect ...
+func (d *dnsSearchProbe) Collect(ch chan<- Metric) {
+	d.dnsSearchStats.Collect(ch)
+}
+
+// AddStat ...
+func (d *dnsSearchProbe) AddStat(stat dnsSearchStat) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.dnsSearchStats.values = append(d.dnsSearchStats.values, stat)
+}
+
+// Do ...
+func (d *dnsSearchProbe) Do(ctx context.Context, metrics Gatherer) func() error {
+	return func() error {
+		err := d.configure(d.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(d.opts.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				// the resolv.conf is read every round, to follow changes of the search list
+				conf, err := loadResolvConf(d.resolvConfPath())
+				if err != nil {
+					return err
+				}
+
+				d.do(ctx, conf)
+
+				metrics.Gather(d)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+// resolvConfPath returns the path of the resolv.conf to read.
+// On nodes with systemd-resolved the resolv.conf points at the local stub resolver 127.0.0.53,
+// so the resolv.conf of systemd-resolved with the upstream name servers is preferred, if present.
+func (d *dnsSearchProbe) resolvConfPath() string {
+	if d.resolvedDir != "" {
+		path := filepath.Join(d.resolvedDir, "resolv.conf")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return d.resolvConf
+}
+
+func (d *dnsSearchProbe) do(ctx context.Context, conf *resolvConf) {
+	d.Reset()
+
+	for _, name := range d.names {
+		name := name
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+
+			d.sem <- token{}
+			d.AddStat(d.lookup(ctx, conf, name))
+			<-d.sem
+		}()
+	}
+
+	d.wg.Wait()
+}
+
+// qtypes returns the types to query for the families of the probe, which are A and AAAA by default.
+func (d *dnsSearchProbe) qtypes() []dnsmessage.Type {
+	qtypes := make([]dnsmessage.Type, 0, 2)
+
+	for _, family := range d.opts.ipFamilies {
+		switch family {
+		case v1alpha1.IPFamilyIPv4:
+			qtypes = append(qtypes, dnsmessage.TypeA)
+		case v1alpha1.IPFamilyIPv6:
+			qtypes = append(qtypes, dnsmessage.TypeAAAA)
+		}
+	}
+
+	if len(qtypes) == 0 {
+		return []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	}
+
+	return qtypes
+}
+
+// lookup is resolving a name through the search list like the resolver of a pod.
+// The names of the list are queried in order until a name has answers,
+// with the queries of all types of a name in parallel.
+func (d *dnsSearchProbe) lookup(ctx context.Context, conf *resolvConf, name string) dnsSearchStat {
+	stat := dnsSearchStat{name: name}
+
+	if len(conf.servers) == 0 {
+		stat.err = ErrDNSNoServer
+		return stat
+	}
+	stat.server = conf.servers[0]
+
+	ctx, cancel := context.WithTimeout(ctx, d.opts.timeout)
+	defer cancel()
+
+	qtypes := d.qtypes()
+	start := time.Now()
+
+	for _, fqdn := range conf.nameList(name) {
+		domain := strings.TrimSuffix(strings.TrimPrefix(fqdn, strings.TrimSuffix(name, ".")+"."), ".")
+		if domain == "" {
+			domain = absoluteSearchDomain
+		}
+
+		answers := make([]int, len(qtypes))
+		errs := make([]error, len(qtypes))
+		times := make([]float64, len(qtypes))
+
+		var wg sync.WaitGroup
+		for i, qtype := range qtypes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				queryStart := time.Now()
+				answers[i], errs[i] = d.query(ctx, stat.server, fqdn, qtype)
+				times[i] = float64(time.Since(queryStart).Microseconds())
+			}()
+		}
+		wg.Wait()
+
+		total := 0
+		stat.err = nil
+
+		for i := range qtypes {
+			stat.queries = append(stat.queries, dnsSearchQuery{domain: domain, time: times[i]})
+			total += answers[i]
+
+			if errs[i] != nil {
+				stat.err = errs[i]
+			}
+		}
+
+		if total > 0 {
+			stat.err = nil
+			break
+		}
+
+		if stat.err == nil {
+			stat.err = ErrDNSNoAnswer
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	stat.time = float64(time.Since(start).Microseconds())
+
+	return stat
+}
+
+// query returns the number of answers of a type for a name.
+func (d *dnsSearchProbe) query(ctx context.Context, server, name string, qtype dnsmessage.Type) (int, error) {
+	query, err := newDNSQuery(name, qtype)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := exchangeDNS(ctx, server, query)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		return 0, &DNSRCodeError{RCode: resp.RCode}
+	}
+
+	return len(dnsAnswers(resp, qtype)), nil
+}
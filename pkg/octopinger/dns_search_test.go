@@ -0,0 +1,117 @@
+package octopinger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadResolvConf(t *testing.T) {
+	resolvConf := filepath.Join(t.TempDir(), "resolv.conf")
+	assert.NoError(t, os.WriteFile(resolvConf, []byte(`search default.svc.cluster.local svc.cluster.local cluster.local.
+nameserver 10.96.0.10
+options ndots:5 timeout:2
+`), 0o600))
+
+	conf, err := loadResolvConf(resolvConf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.96.0.10:53"}, conf.servers)
+	assert.Equal(t, []string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"}, conf.search)
+	assert.Equal(t, 5, conf.ndots)
+
+	assert.Equal(t, []string{
+		"kubernetes.default.default.svc.cluster.local.",
+		"kubernetes.default.svc.cluster.local.",
+		"kubernetes.default.cluster.local.",
+		"kubernetes.default.",
+	}, conf.nameList("kubernetes.default"))
+	assert.Equal(t, []string{"www.ionos.com."}, conf.nameList("www.ionos.com."))
+
+	conf.ndots = 1
+	assert.Equal(t, []string{
+		"www.ionos.com.",
+		"www.ionos.com.default.svc.cluster.local.",
+		"www.ionos.com.svc.cluster.local.",
+		"www.ionos.com.cluster.local.",
+	}, conf.nameList("www.ionos.com"))
+}
+
+func TestDNSSearchProbe(t *testing.T) {
+	server := serveDNS(t, testZone)
+
+	conf := &resolvConf{
+		servers: []string{server},
+		search:  []string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"},
+		ndots:   5,
+	}
+
+	tests := []struct {
+		name     string
+		families []v1alpha1.IPFamily
+		queries  int
+		class    string
+	}{
+		{name: "kubernetes.default", families: []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4}, queries: 2},
+		{name: "www.ionos.com", families: []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4}, queries: 4},
+		{name: "www.ionos.com.", families: []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4}, queries: 1},
+		{name: "kubernetes.default", queries: 4},
+		{name: "monalisa", families: []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4}, queries: 4, class: DNSErrorNXDomain},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewDNSSearchProbe("monalisa", []string{tc.name}, WithIPFamilies(tc.families), WithTimeout(time.Second))
+
+			stat := p.lookup(context.Background(), conf, tc.name)
+
+			assert.Equal(t, server, stat.server)
+			assert.Len(t, stat.queries, tc.queries)
+			assert.Positive(t, stat.time)
+
+			if tc.class == "" {
+				assert.NoError(t, stat.err)
+			} else {
+				assert.Equal(t, tc.class, DNSErrorClass(stat.err))
+			}
+		})
+	}
+}
+
+func TestDNSSearchProbeWrite(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	stats := NewDNSSearchStats("dns_search", "monalisa")
+	stats.values = []dnsSearchStat{
+		{name: "kubernetes.default", queries: []dnsSearchQuery{{domain: "default.svc.cluster.local", time: 300}, {domain: "svc.cluster.local", time: 100}}, time: 400},
+		{name: "monalisa", queries: []dnsSearchQuery{{domain: "default.svc.cluster.local", time: 100}, {domain: absoluteSearchDomain, time: 100}}, time: 200, err: ErrDNSNoAnswer},
+	}
+
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 3, testutil.CollectAndCount(m, "octopinger_probe_dns_search_domain_time"))
+	assert.Equal(t, float64(200), testutil.ToFloat64(m.probeDNSSearchDomain.WithLabelValues("monalisa", "default.svc.cluster.local")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.probeDNSSearchQueries.WithLabelValues("monalisa", "kubernetes.default")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.probeDNSSearchError.WithLabelValues("monalisa", "monalisa", DNSErrorNoAnswer)))
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_dns_search_seconds"))
+}
+
+func TestDNSSearchProbeResolvConfPath(t *testing.T) {
+	d := NewDNSSearchProbe("monalisa", nil)
+	assert.Equal(t, "/etc/resolv.conf", d.resolvConfPath())
+
+	d.resolvConf = NodeResolvConf
+	d.resolvedDir = t.TempDir()
+	assert.Equal(t, NodeResolvConf, d.resolvConfPath())
+
+	// the resolv.conf of systemd-resolved is preferred
+	resolved := filepath.Join(d.resolvedDir, "resolv.conf")
+	assert.NoError(t, os.WriteFile(resolved, []byte("nameserver 10.0.0.53\n"), 0o600))
+	assert.Equal(t, resolved, d.resolvConfPath())
+}
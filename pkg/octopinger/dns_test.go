@@ -0,0 +1,107 @@
+package octopinger
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDNSEndpointsLoader(t *testing.T) {
+	dir := t.TempDir()
+
+	endpoints, err := DNSEndpointsLoader(dir)()
+	assert.NoError(t, err)
+	assert.Empty(t, endpoints)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "dns_endpoints"), []byte(`[{"name":"coredns-a","node":"node-a","ips":["10.244.0.2","fd00:10:244::2"],"port":53}]`), 0o600))
+
+	endpoints, err = DNSEndpointsLoader(dir)()
+	assert.NoError(t, err)
+	assert.Equal(t, []DNSEndpoint{{Name: "coredns-a", Node: "node-a", IPs: []string{"10.244.0.2", "fd00:10:244::2"}, Port: 53}}, endpoints)
+}
+
+func TestDNSService(t *testing.T) {
+	namespace, name, err := DNSService("")
+	assert.NoError(t, err)
+	assert.Equal(t, "kube-system", namespace)
+	assert.Equal(t, "kube-dns", name)
+
+	_, _, err = DNSService("coredns")
+	assert.Error(t, err)
+}
+
+func TestDNSProbeEndpoints(t *testing.T) {
+	server := serveDNS(t, testZone)
+
+	p := NewDNSProbe("monalisa", "", []string{"www.ionos.com."},
+		WithIPFamilies([]v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4}),
+		WithTimeout(time.Second),
+		WithConfig(&v1alpha1.Config{DNS: v1alpha1.DNS{Endpoints: true}}),
+	)
+	assert.True(t, p.endpoints)
+
+	host, portStr, err := net.SplitHostPort(server)
+	assert.NoError(t, err)
+
+	port, err := strconv.Atoi(portStr)
+	assert.NoError(t, err)
+
+	servers := p.endpointServers([]DNSEndpoint{
+		{Name: "coredns-a", Node: "node-a", IPs: []string{host, "fd00:10:244::2"}, Port: port},
+		{Name: "coredns-b", Node: "node-b", IPs: []string{"127.0.0.1"}, Port: 1},
+	})
+	assert.Len(t, servers, 2)
+
+	p.do(context.Background(), servers, p.names...)
+
+	assert.Len(t, p.dnsResults.values, 2)
+	assert.Equal(t, float64(1), p.dnsSuccess.values[v1alpha1.IPFamilyIPv4])
+	assert.Equal(t, float64(1), p.dnsError.values[v1alpha1.IPFamilyIPv4])
+
+	for _, v := range p.dnsEndpoints.values {
+		switch v.endpoint {
+		case "coredns-a":
+			assert.Equal(t, server, v.ip)
+			assert.Equal(t, float64(1), v.successes)
+		case "coredns-b":
+			assert.Equal(t, "node-b", v.node)
+			assert.Equal(t, float64(1), v.errors)
+		}
+	}
+}
+
+func TestDNSProbeUpstream(t *testing.T) {
+	dot, doh, caFile := serveEncryptedDNS(t, testZone)
+	dnsCA := &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "dns-ca"}, Key: "ca.crt"}
+
+	for _, server := range []string{dot, doh} {
+		t.Run(server, func(t *testing.T) {
+			p := NewDNSProbe("monalisa", server, []string{"www.ionos.com", "monalisa.ionos.com"},
+				WithTimeout(time.Second),
+				WithConfig(&v1alpha1.Config{DNS: v1alpha1.DNS{TLS: v1alpha1.DNSTLS{CAConfigMap: dnsCA}}}),
+			)
+			p.caFile = caFile
+			assert.NoError(t, p.configure(p.opts.config))
+			assert.NotNil(t, p.upstream)
+
+			p.do(context.Background(), []dnsServer{{upstream: p.upstream, address: server}}, p.names...)
+
+			assert.Len(t, p.dnsResults.values, 2)
+			assert.Equal(t, float64(1), p.dnsSuccess.values[""])
+			assert.Equal(t, float64(1), p.dnsError.values[""])
+
+			assert.Len(t, p.dnsUpstreams.values, 1)
+			assert.Equal(t, server, p.dnsUpstreams.values[0].server)
+			assert.Len(t, p.dnsUpstreams.values[0].times, 1)
+			assert.Equal(t, []string{DNSErrorNXDomain}, p.dnsUpstreams.values[0].errors)
+		})
+	}
+}
@@ -0,0 +1,561 @@
+package octopinger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	defaultResolvConf = "/etc/resolv.conf"
+	defaultDNSPort    = "53"
+	defaultDoTPort    = "853"
+	defaultNdots      = 1
+	maxNdots          = 15
+
+	maxDNSMessageSize = 65535
+
+	dnsMessageContentType = "application/dns-message"
+)
+
+const (
+	// DNSCADir is the directory of the certificate authorities of the encrypted name servers in the Octopinger pod.
+	DNSCADir = "/etc/octopinger/dns-ca"
+	// DNSCAFile is the name of the file with the certificate authorities in the DNSCADir.
+	DNSCAFile = "ca.crt"
+)
+
+const (
+	// DNSTransportDNS is the transport of plain DNS over UDP and TCP.
+	DNSTransportDNS = "dns"
+	// DNSTransportDoT is the transport of DNS-over-TLS (RFC 7858).
+	DNSTransportDoT = "dot"
+	// DNSTransportDoH is the transport of DNS-over-HTTPS (RFC 8484).
+	DNSTransportDoH = "doh"
+)
+
+var (
+	// ErrDNSResponse ...
+	ErrDNSResponse = errors.New("invalid dns response")
+	// ErrDNSServer ...
+	ErrDNSServer = errors.New("invalid dns server")
+)
+
+var dnsRecordTypes = map[v1alpha1.DNSRecordType]dnsmessage.Type{
+	v1alpha1.DNSRecordTypeA:     dnsmessage.TypeA,
+	v1alpha1.DNSRecordTypeAAAA:  dnsmessage.TypeAAAA,
+	v1alpha1.DNSRecordTypeCNAME: dnsmessage.TypeCNAME,
+	v1alpha1.DNSRecordTypeSRV:   dnsmessage.TypeSRV,
+	v1alpha1.DNSRecordTypeMX:    dnsmessage.TypeMX,
+	v1alpha1.DNSRecordTypeTXT:   dnsmessage.TypeTXT,
+	v1alpha1.DNSRecordTypePTR:   dnsmessage.TypePTR,
+}
+
+// resolvConf is the resolver configuration of the pod.
+type resolvConf struct {
+	servers []string
+	search  []string
+	ndots   int
+}
+
+// loadResolvConf is reading the name servers, the search list and the ndots option from a resolv.conf file.
+func loadResolvConf(path string) (*resolvConf, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	conf := &resolvConf{ndots: defaultNdots}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "nameserver":
+			conf.servers = append(conf.servers, dnsServerAddress(fields[1]))
+		case "domain":
+			conf.search = nil
+			if domain := normalizeName(fields[1]); domain != "" {
+				conf.search = []string{domain}
+			}
+		case "search":
+			// the last search or domain line wins
+			conf.search = make([]string, 0, len(fields)-1)
+			for _, domain := range fields[1:] {
+				if domain := normalizeName(domain); domain != "" {
+					conf.search = append(conf.search, domain)
+				}
+			}
+		case "options":
+			for _, option := range fields[1:] {
+				value, ok := strings.CutPrefix(option, "ndots:")
+				if !ok {
+					continue
+				}
+
+				if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+					conf.ndots = min(n, maxNdots)
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// nameList returns the names to query for a name in the order of the resolver of glibc.
+// Names with a trailing dot are queried as they are, names with at least ndots dots
+// are queried before and all other names after the names of the search list.
+func (c *resolvConf) nameList(name string) []string {
+	if strings.HasSuffix(name, ".") {
+		return []string{name}
+	}
+
+	names := make([]string, 0, len(c.search)+1)
+
+	absolute := strings.Count(name, ".") >= c.ndots
+	if absolute {
+		names = append(names, name+".")
+	}
+
+	for _, domain := range c.search {
+		names = append(names, name+"."+domain+".")
+	}
+
+	if !absolute {
+		names = append(names, name+".")
+	}
+
+	return names
+}
+
+// dnsServerAddress returns the address of a name server, with the default port if it has none.
+func dnsServerAddress(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+
+	return net.JoinHostPort(server, defaultDNSPort)
+}
+
+// dnsUpstream is a name server, which is queried over plain DNS, DNS-over-TLS or DNS-over-HTTPS.
+type dnsUpstream struct {
+	server    string
+	transport string
+	address   string
+	client    *http.Client
+	tlsConfig *tls.Config
+}
+
+// newDNSTLSConfig returns the TLS configuration to verify encrypted name servers.
+// The certificate authorities of the ConfigMap are read from the file, where it is mounted.
+func newDNSTLSConfig(opts v1alpha1.DNSTLS, caFile string) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if opts.CAConfigMap != nil {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+	}
+
+	return config, nil
+}
+
+// parseDNSServer returns the transport and the address of a server,
+// which is the URL for DNS-over-HTTPS and host:port otherwise.
+func parseDNSServer(server string) (string, string, error) {
+	if !strings.Contains(server, "://") {
+		return DNSTransportDNS, dnsServerAddress(server), nil
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrDNSServer, err)
+	}
+
+	if u.Hostname() == "" {
+		return "", "", fmt.Errorf("%w: %s", ErrDNSServer, server)
+	}
+
+	switch u.Scheme {
+	case "dot":
+		if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+			return "", "", fmt.Errorf("%w: %s", ErrDNSServer, server)
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = defaultDoTPort
+		}
+
+		return DNSTransportDoT, net.JoinHostPort(u.Hostname(), port), nil
+	case "https":
+		return DNSTransportDoH, u.String(), nil
+	}
+
+	return "", "", fmt.Errorf("%w: unsupported scheme %s", ErrDNSServer, u.Scheme)
+}
+
+// newDNSUpstream returns the upstream of a server. The TLS configuration is used for encrypted servers,
+// which are verified against their host if the configuration has no server name.
+func newDNSUpstream(server string, config *tls.Config) (*dnsUpstream, error) {
+	transport, address, err := parseDNSServer(server)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &dnsUpstream{
+		server:    server,
+		transport: transport,
+		address:   address,
+	}
+
+	if transport == DNSTransportDNS {
+		return u, nil
+	}
+
+	if config == nil {
+		config = new(tls.Config)
+	}
+
+	u.tlsConfig = config.Clone()
+
+	if u.tlsConfig.ServerName == "" {
+		host := address
+		if transport == DNSTransportDoH {
+			parsed, _ := url.Parse(address)
+			host = parsed.Host
+		}
+
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		u.tlsConfig.ServerName = strings.Trim(host, "[]")
+	}
+
+	if transport == DNSTransportDoH {
+		// every query is establishing a new connection,
+		// so that the handshake is part of the time and a broken certificate is not hidden by an idle connection
+		u.client = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   u.tlsConfig,
+				DisableKeepAlives: true,
+				ForceAttemptHTTP2: true,
+			},
+		}
+	}
+
+	return u, nil
+}
+
+// exchange is sending the query to the upstream over its transport.
+func (u *dnsUpstream) exchange(ctx context.Context, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	switch u.transport {
+	case DNSTransportDoT:
+		return exchangeDNSOverTLS(ctx, u.address, u.tlsConfig, query)
+	case DNSTransportDoH:
+		return exchangeDNSOverHTTPS(ctx, u.client, u.address, query)
+	}
+
+	return exchangeDNS(ctx, u.address, query)
+}
+
+// newDNSQuery returns a recursive query for the records of a name.
+// The name of PTR queries can be an IP address, which is converted into its reverse name.
+func newDNSQuery(name string, qtype dnsmessage.Type) (dnsmessage.Message, error) {
+	if qtype == dnsmessage.TypePTR {
+		if ip, err := netip.ParseAddr(name); err == nil {
+			name = reverseName(ip)
+		}
+	}
+
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	return dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               uint16(rand.Intn(0xffff)),
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{
+			{Name: n, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}, nil
+}
+
+// reverseName returns the name of the PTR record of an IP address.
+func reverseName(ip netip.Addr) string {
+	var b strings.Builder
+
+	if ip.Is4() || ip.Is4In6() {
+		ip4 := ip.Unmap().As4()
+		for i := len(ip4) - 1; i >= 0; i-- {
+			b.WriteString(strconv.Itoa(int(ip4[i])))
+			b.WriteByte('.')
+		}
+
+		b.WriteString("in-addr.arpa.")
+
+		return b.String()
+	}
+
+	ip16 := ip.As16()
+	for i := len(ip16) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%x.%x.", ip16[i]&0x0f, ip16[i]>>4)
+	}
+
+	b.WriteString("ip6.arpa.")
+
+	return b.String()
+}
+
+// exchangeDNS is sending the query to the server over UDP, and retries over TCP if the response is truncated.
+func exchangeDNS(ctx context.Context, server string, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	resp, err := exchangeDNSOver(ctx, "udp", server, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Truncated {
+		return exchangeDNSOver(ctx, "tcp", server, query)
+	}
+
+	return resp, nil
+}
+
+func exchangeDNSOver(ctx context.Context, network, server string, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	return exchangeDNSConn(ctx, conn, network == "tcp", query)
+}
+
+// exchangeDNSOverTLS is sending the query over a TLS connection, with the framing of TCP.
+func exchangeDNSOverTLS(ctx context.Context, server string, config *tls.Config, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	d := tls.Dialer{Config: config}
+	conn, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	return exchangeDNSConn(ctx, conn, true, query)
+}
+
+// exchangeDNSOverHTTPS is posting the query to the URL of the server.
+func exchangeDNSOverHTTPS(ctx context.Context, client *http.Client, server string, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", dnsMessageContentType)
+	req.Header.Set("Accept", dnsMessageContentType)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", ErrHTTPStatus, res.StatusCode)
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(res.Body, maxDNSMessageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDNSResponse, err)
+	}
+
+	if !resp.Response || resp.ID != query.ID {
+		return nil, ErrDNSResponse
+	}
+
+	return &resp, nil
+}
+
+func exchangeDNSConn(ctx context.Context, conn net.Conn, stream bool, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// messages over TCP are prefixed with their length
+	if stream {
+		packed = append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...)
+	}
+
+	_, err = conn.Write(packed)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		buf, err := readDNSMessage(conn, stream)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDNSResponse, err)
+		}
+
+		// late responses to earlier queries are skipped
+		if !resp.Response || resp.ID != query.ID {
+			if stream {
+				return nil, ErrDNSResponse
+			}
+
+			continue
+		}
+
+		return &resp, nil
+	}
+}
+
+func readDNSMessage(conn net.Conn, stream bool) ([]byte, error) {
+	if !stream {
+		buf := make([]byte, maxDNSMessageSize)
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// dnsAnswers returns the answers of the type in the presentation format of dig,
+// with lower case names and without the trailing dots.
+func dnsAnswers(resp *dnsmessage.Message, qtype dnsmessage.Type) []string {
+	answers := make([]string, 0, len(resp.Answers))
+
+	for _, rr := range resp.Answers {
+		if rr.Header.Type != qtype {
+			continue
+		}
+
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			answers = append(answers, netip.AddrFrom4(body.A).String())
+		case *dnsmessage.AAAAResource:
+			answers = append(answers, netip.AddrFrom16(body.AAAA).String())
+		case *dnsmessage.CNAMEResource:
+			answers = append(answers, presentName(body.CNAME))
+		case *dnsmessage.PTRResource:
+			answers = append(answers, presentName(body.PTR))
+		case *dnsmessage.MXResource:
+			answers = append(answers, fmt.Sprintf("%d %s", body.Pref, presentName(body.MX)))
+		case *dnsmessage.SRVResource:
+			answers = append(answers, fmt.Sprintf("%d %d %d %s", body.Priority, body.Weight, body.Port, presentName(body.Target)))
+		case *dnsmessage.TXTResource:
+			answers = append(answers, strings.Join(body.TXT, ""))
+		}
+	}
+
+	return answers
+}
+
+func presentName(n dnsmessage.Name) string {
+	return normalizeName(n.String())
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// normalizeAnswer is converting an expected answer into the format of dnsAnswers.
+func normalizeAnswer(qtype dnsmessage.Type, answer string) string {
+	switch qtype {
+	case dnsmessage.TypeTXT:
+		return answer
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		if ip, err := netip.ParseAddr(answer); err == nil {
+			return ip.String()
+		}
+	}
+
+	fields := strings.Fields(answer)
+	for i := range fields {
+		fields[i] = normalizeName(fields[i])
+	}
+
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,194 @@
+package octopinger
+
+import (
+	"context"
+	crand "crypto/rand"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+const (
+	icmpHeaderLen = 8
+
+	protocolICMP   = 1
+	protocolICMPv6 = 58
+
+	// maxEchoSize is the size of the read buffer, which fits jumbo frames.
+	maxEchoSize = 0xffff
+)
+
+// EchoFunc is sending an ICMP echo request as an IP packet of the size to the IP
+// and is waiting for the reply.
+type EchoFunc func(ctx context.Context, ip string, size int) error
+
+type echoKey struct {
+	ip  string
+	seq int
+}
+
+// echoSession is sending echo requests with the don't fragment bit set,
+// so that packets larger than the path MTU are dropped instead of fragmented.
+// The requests share one socket per family, and the replies are matched to the
+// requests by their sequence number and a random token in the payload, as in Ping.
+type echoSession struct {
+	socket  v1alpha1.ICMPSocket
+	timeout time.Duration
+
+	id    int
+	token []byte
+	seq   atomic.Uint32
+
+	conns    map[v1alpha1.IPFamily]*pingConn
+	connErrs map[v1alpha1.IPFamily]error
+	pending  map[echoKey]chan struct{}
+
+	readers sync.WaitGroup
+	mux     sync.Mutex
+}
+
+func newEchoSession(socket v1alpha1.ICMPSocket, timeout time.Duration) (*echoSession, error) {
+	token := make([]byte, pingTokenLen)
+	if _, err := crand.Read(token); err != nil {
+		return nil, err
+	}
+
+	return &echoSession{
+		socket:   socket,
+		timeout:  timeout,
+		id:       rand.Intn(0xffff),
+		token:    token,
+		conns:    make(map[v1alpha1.IPFamily]*pingConn),
+		connErrs: make(map[v1alpha1.IPFamily]error),
+		pending:  make(map[echoKey]chan struct{}),
+	}, nil
+}
+
+// Echo is an EchoFunc that is sending the request over the socket of the family of the IP.
+func (s *echoSession) Echo(ctx context.Context, ip string, size int) error {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return fmt.Errorf("invalid ip: %s", ip)
+	}
+
+	conn, err := s.conn(IPFamilyOf(ip))
+	if err != nil {
+		return err
+	}
+
+	payload := size - conn.headerLen() - icmpHeaderLen
+	if payload < len(s.token) {
+		return fmt.Errorf("invalid size: %d", size)
+	}
+
+	data := make([]byte, payload)
+	copy(data, s.token)
+
+	key := echoKey{ip: addr.String(), seq: int(uint16(s.seq.Add(1)))}
+	replied := make(chan struct{})
+
+	s.mux.Lock()
+	s.pending[key] = replied
+	s.mux.Unlock()
+
+	defer func() {
+		s.mux.Lock()
+		delete(s.pending, key)
+		s.mux.Unlock()
+	}()
+
+	err = conn.write(addr, s.id, key.seq, data)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-replied:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("timeout after %s", s.timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close is closing the sockets and waits for their readers.
+func (s *echoSession) Close() error {
+	s.mux.Lock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mux.Unlock()
+
+	s.readers.Wait()
+
+	return nil
+}
+
+// conn returns the socket of the family, which is opened on first use.
+func (s *echoSession) conn(family v1alpha1.IPFamily) (*pingConn, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if conn, ok := s.conns[family]; ok {
+		return conn, nil
+	}
+
+	if err := s.connErrs[family]; err != nil {
+		return nil, err
+	}
+
+	conn, err := listenPing(family, s.socket, "")
+	if err == nil {
+		err = conn.control(func(fd uintptr) error {
+			return setDontFragment(fd, family)
+		})
+		if err != nil {
+			_ = conn.Close()
+		}
+	}
+
+	if err != nil {
+		s.connErrs[family] = err
+		return nil, err
+	}
+
+	s.conns[family] = conn
+
+	s.readers.Add(1)
+	go s.read(conn)
+
+	return conn, nil
+}
+
+func (s *echoSession) read(conn *pingConn) {
+	defer s.readers.Done()
+
+	buf := make([]byte, maxEchoSize)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		ip, e, ok := conn.reply(buf[:n], peer, s.id, s.token)
+		if !ok {
+			continue
+		}
+
+		s.mux.Lock()
+		if replied, ok := s.pending[echoKey{ip: ip.String(), seq: e.Seq}]; ok {
+			delete(s.pending, echoKey{ip: ip.String(), seq: e.Seq})
+			close(replied)
+		}
+		s.mux.Unlock()
+	}
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package octopinger
+
+import (
+	"syscall"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+func setDontFragment(fd uintptr, family v1alpha1.IPFamily) error {
+	if family == v1alpha1.IPFamilyIPv6 {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, syscall.IPV6_PMTUDISC_DO)
+	}
+
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_DO)
+}
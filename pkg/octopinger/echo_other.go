@@ -0,0 +1,13 @@
+//go:build !linux
+
+package octopinger
+
+import (
+	"errors"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+func setDontFragment(_ uintptr, _ v1alpha1.IPFamily) error {
+	return errors.New("don't fragment is not supported on this platform")
+}
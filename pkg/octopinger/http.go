@@ -0,0 +1,417 @@
+package octopinger
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+const (
+	// HTTPErrorStatus is the error class of an unexpected status code.
+	HTTPErrorStatus = "status"
+	// HTTPErrorBody is the error class of a body that does not match.
+	HTTPErrorBody = "body"
+	// HTTPErrorTLS is the error class of a failed TLS handshake.
+	HTTPErrorTLS = "tls"
+
+	maxHTTPBodySize = 1 << 20
+)
+
+var (
+	// ErrHTTPStatus ...
+	ErrHTTPStatus = errors.New("unexpected status code")
+	// ErrHTTPBody ...
+	ErrHTTPBody = errors.New("body does not match")
+)
+
+// HTTPErrorClass returns the class of a request error.
+func HTTPErrorClass(err error) string {
+	switch {
+	case errors.Is(err, ErrHTTPStatus):
+		return HTTPErrorStatus
+	case errors.Is(err, ErrHTTPBody):
+		return HTTPErrorBody
+	case isTLSError(err):
+		return HTTPErrorTLS
+	}
+
+	return TCPErrorClass(err)
+}
+
+// isTLSError returns true for errors of the handshake and the verification of the certificates.
+func isTLSError(err error) bool {
+	var (
+		certErr    *tls.CertificateVerificationError
+		alertErr   tls.AlertError
+		recordErr  tls.RecordHeaderError
+		unknownErr x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+
+	return errors.As(err, &certErr) || errors.As(err, &alertErr) || errors.As(err, &recordErr) ||
+		errors.As(err, &unknownErr) || errors.As(err, &hostErr) || errors.As(err, &invalidErr)
+}
+
+type httpCheck struct {
+	url        string
+	method     string
+	headers    map[string]string
+	statusCode map[int]bool
+	bodyRegex  *regexp.Regexp
+	timeout    time.Duration
+}
+
+// NewHTTPCheck ...
+func NewHTTPCheck(target v1alpha1.HTTPTarget) (*httpCheck, error) {
+	c := new(httpCheck)
+	c.url = target.URL
+	c.method = http.MethodGet
+	c.headers = target.Headers
+	c.timeout = defaultTimeout
+
+	if target.Method != "" {
+		c.method = strings.ToUpper(target.Method)
+	}
+
+	if len(target.ExpectedStatusCodes) > 0 {
+		c.statusCode = make(map[int]bool, len(target.ExpectedStatusCodes))
+		for _, code := range target.ExpectedStatusCodes {
+			c.statusCode[code] = true
+		}
+	}
+
+	if target.BodyRegex != "" {
+		r, err := regexp.Compile(target.BodyRegex)
+		if err != nil {
+			return nil, err
+		}
+
+		c.bodyRegex = r
+	}
+
+	if target.Timeout != "" {
+		s, err := time.ParseDuration(target.Timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		c.timeout = s
+	}
+
+	return c, nil
+}
+
+func (c *httpCheck) expectStatusCode(code int) bool {
+	if c.statusCode == nil {
+		return code >= 200 && code < 300
+	}
+
+	return c.statusCode[code]
+}
+
+type httpStat struct {
+	target      string
+	statusCode  int
+	dnsTime     time.Duration
+	connectTime time.Duration
+	tlsTime     time.Duration
+	ttfb        time.Duration
+	totalTime   time.Duration
+	err         error
+}
+
+type httpStats struct {
+	values []httpStat
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (h *httpStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeHTTP(h.nodeName)
+
+	results := make([]Result, 0, len(h.values))
+
+	for _, v := range h.values {
+		result := Result{
+			Target: v.target,
+		}
+
+		if v.statusCode > 0 {
+			monitor.SetProbeHTTPStatusCode(h.nodeName, v.target, float64(v.statusCode))
+		}
+
+		if v.err != nil {
+			monitor.IncProbeHTTPError(h.nodeName, v.target, HTTPErrorClass(v.err))
+
+			result.Loss = 1
+			result.Error = v.err.Error()
+		} else {
+			monitor.IncProbeHTTPSuccess(h.nodeName, v.target)
+			monitor.SetProbeHTTPDNSTime(h.nodeName, v.target, float64(v.dnsTime.Microseconds()))
+			monitor.SetProbeHTTPConnectTime(h.nodeName, v.target, float64(v.connectTime.Microseconds()))
+			monitor.SetProbeHTTPTLSTime(h.nodeName, v.target, float64(v.tlsTime.Microseconds()))
+			monitor.SetProbeHTTPTTFB(h.nodeName, v.target, float64(v.ttfb.Microseconds()))
+			monitor.SetProbeHTTPTotalTime(h.nodeName, v.target, float64(v.totalTime.Microseconds()))
+			monitor.ObserveProbeHTTPTotalTime(h.nodeName, v.target, float64(v.totalTime.Microseconds()))
+
+			result.RttMin = float64(v.totalTime.Microseconds())
+			result.RttMean = float64(v.totalTime.Microseconds())
+			result.RttMax = float64(v.totalTime.Microseconds())
+		}
+
+		results = append(results, result)
+	}
+
+	monitor.SetProbeResults(h.nodeName, h.probeName, results)
+
+	return nil
+}
+
+// Collect ...
+func (h *httpStats) Collect(ch chan<- Metric) {
+	ch <- h
+}
+
+// NewHTTPStats ...
+func NewHTTPStats(probeName, nodeName string) *httpStats {
+	return &httpStats{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+type httpProbe struct {
+	opts *Opts
+
+	name     string
+	nodeName string
+	checks   []*httpCheck
+
+	httpStats *httpStats
+
+	maxConcurrency int
+
+	sem chan token
+	wg  sync.WaitGroup
+	mux sync.Mutex
+}
+
+// NewHTTPProbe ...
+func NewHTTPProbe(nodeName string, opts ...Opt) *httpProbe {
+	options := new(Opts)
+	options.Configure(opts...)
+
+	h := new(httpProbe)
+	h.opts = options
+	h.name = "http"
+	h.nodeName = nodeName
+	h.maxConcurrency = 100
+	h.sem = make(chan token, h.maxConcurrency)
+
+	h.Reset()
+
+	return h
+}
+
+func (h *httpProbe) configure(c *v1alpha1.Config) error {
+	checks := make([]*httpCheck, 0, len(c.HTTP.Targets))
+
+	for _, target := range c.HTTP.Targets {
+		check, err := NewHTTPCheck(target)
+		if err != nil {
+			return err
+		}
+
+		checks = append(checks, check)
+	}
+
+	h.checks = checks
+
+	return nil
+}
+
+// Name ...
+func (h *httpProbe) Name() string {
+	return h.name
+}
+
+// Reset ...
+func (h *httpProbe) Reset() {
+	h.httpStats = NewHTTPStats(h.name, h.nodeName)
+}
+
+// Collect ...
+func (h *httpProbe) Collect(ch chan<- Metric) {
+	h.httpStats.Collect(ch)
+}
+
+// AddStat ...
+func (h *httpProbe) AddStat(stat httpStat) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.httpStats.values = append(h.httpStats.values, stat)
+}
+
+// Do ...
+func (h *httpProbe) Do(ctx context.Context, metrics Gatherer) func() error {
+	return func() error {
+		err := h.configure(h.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(h.opts.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				h.do(ctx, h.checks...)
+
+				metrics.Gather(h)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+func (h *httpProbe) do(ctx context.Context, checks ...*httpCheck) {
+	h.Reset()
+
+	for _, check := range checks {
+		check := check
+
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+
+			h.sem <- token{}
+
+			h.AddStat(h.request(ctx, check))
+
+			<-h.sem
+		}()
+	}
+
+	h.wg.Wait()
+}
+
+func (h *httpProbe) request(ctx context.Context, check *httpCheck) httpStat {
+	stat := httpStat{target: check.url}
+
+	ctx, cancel := context.WithTimeout(ctx, check.timeout)
+	defer cancel()
+
+	var mux sync.Mutex
+	var dnsStart, tlsStart time.Time
+	var connected bool
+	connectStart := map[string]time.Time{}
+
+	// the dialer may connect to multiple addresses in parallel,
+	// only the first successful connection is recorded
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			stat.dnsTime = time.Since(dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			mux.Lock()
+			defer mux.Unlock()
+
+			connectStart[network+"/"+addr] = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mux.Lock()
+			defer mux.Unlock()
+
+			if err != nil || connected {
+				return
+			}
+
+			connected = true
+			stat.connectTime = time.Since(connectStart[network+"/"+addr])
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			stat.tlsTime = time.Since(tlsStart)
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), check.method, check.url, nil)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+
+	for k, v := range check.headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	start := time.Now()
+	trace.GotFirstResponseByte = func() {
+		stat.ttfb = time.Since(start)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxHTTPBodySize))
+	stat.totalTime = time.Since(start)
+	stat.statusCode = res.StatusCode
+
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+
+	if !check.expectStatusCode(res.StatusCode) {
+		stat.err = fmt.Errorf("%w: %d", ErrHTTPStatus, res.StatusCode)
+		return stat
+	}
+
+	if check.bodyRegex != nil && !check.bodyRegex.Match(body) {
+		stat.err = fmt.Errorf("%w: %s", ErrHTTPBody, check.bodyRegex)
+	}
+
+	return stat
+}
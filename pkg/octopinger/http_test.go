@@ -0,0 +1,81 @@
+package octopinger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPProbeRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("Hello, World 🐙!"))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc   string
+		target v1alpha1.HTTPTarget
+		class  string
+	}{
+		{
+			desc:   "success",
+			target: v1alpha1.HTTPTarget{URL: ts.URL, BodyRegex: "^Hello"},
+		},
+		{
+			desc:   "unexpected status code",
+			target: v1alpha1.HTTPTarget{URL: ts.URL, ExpectedStatusCodes: []int{204}},
+			class:  HTTPErrorStatus,
+		},
+		{
+			desc:   "body does not match",
+			target: v1alpha1.HTTPTarget{URL: ts.URL, BodyRegex: "^Goodbye"},
+			class:  HTTPErrorBody,
+		},
+	}
+
+	p := NewHTTPProbe("monalisa")
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			check, err := NewHTTPCheck(tc.target)
+			assert.NoError(t, err)
+
+			stat := p.request(context.Background(), check)
+			assert.Equal(t, http.StatusOK, stat.statusCode)
+
+			if tc.class == "" {
+				assert.NoError(t, stat.err)
+				assert.Positive(t, stat.connectTime)
+				return
+			}
+
+			assert.Equal(t, tc.class, HTTPErrorClass(stat.err))
+		})
+	}
+}
+
+func TestHTTPStatsWrite(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	stats := NewHTTPStats("http", "monalisa")
+	stats.values = []httpStat{{target: "http://10.0.0.1", statusCode: http.StatusOK, totalTime: 100}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_http_total_time"))
+
+	// a removed target does not keep the values of its last request
+	stats = NewHTTPStats("http", "monalisa")
+	stats.values = []httpStat{{target: "http://10.0.0.2", err: context.DeadlineExceeded}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 0, testutil.CollectAndCount(m, "octopinger_probe_http_total_time"))
+	assert.Equal(t, 0, testutil.CollectAndCount(m, "octopinger_probe_http_status_code"))
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_http_error_total"))
+}
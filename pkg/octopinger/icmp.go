@@ -2,19 +2,23 @@ package octopinger
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"sync"
 	"time"
 
-	"github.com/chenjiandongx/pinger"
 	"github.com/ionos-cloud/octopinger/api/v1alpha1"
 	"github.com/montanaflynn/stats"
 )
 
+// ErrPacketLoss ...
+var ErrPacketLoss = errors.New("all packets lost")
+
 const (
 	defaultPacketLossThreshold = 0.05
 	defaultTimeout             = 5 * time.Second
 	defaultICMPCount           = 5
+	maxICMPCount               = 100
 )
 
 type maxRtt struct {
@@ -204,6 +208,112 @@ func NewPacketLoss(probeName, nodeName string) *packetLoss {
 	}
 }
 
+type targetStat struct {
+	target       string
+	targetNode   string
+	targetZone   string
+	targetRegion string
+	network      string
+	ipFamily     string
+	minRtt       float64
+	meanRtt      float64
+	maxRtt       float64
+	packetLoss   float64
+	duplicates   int
+	reordered    int
+	rtts         []float64
+}
+
+type targetStats struct {
+	values []targetStat
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (m *targetStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeTargets(m.nodeName, m.probeName)
+
+	results := make([]Result, 0, len(m.values))
+
+	for _, v := range m.values {
+		monitor.SetProbeTargetRttMin(m.nodeName, m.probeName, v.target, v.targetNode, v.targetZone, v.network, v.ipFamily, v.minRtt)
+		monitor.SetProbeTargetRttMean(m.nodeName, m.probeName, v.target, v.targetNode, v.targetZone, v.network, v.ipFamily, v.meanRtt)
+		monitor.SetProbeTargetRttMax(m.nodeName, m.probeName, v.target, v.targetNode, v.targetZone, v.network, v.ipFamily, v.maxRtt)
+		monitor.SetProbeTargetLoss(m.nodeName, m.probeName, v.target, v.targetNode, v.targetZone, v.network, v.ipFamily, v.packetLoss)
+		monitor.SetProbeTargetDuplicates(m.nodeName, m.probeName, v.target, v.targetNode, v.targetZone, v.network, v.ipFamily, float64(v.duplicates))
+		monitor.SetProbeTargetReordered(m.nodeName, m.probeName, v.target, v.targetNode, v.targetZone, v.network, v.ipFamily, float64(v.reordered))
+
+		result := Result{
+			Target:     v.target,
+			TargetNode: v.targetNode,
+			TargetZone: v.targetZone,
+			Network:    v.network,
+			IPFamily:   v.ipFamily,
+			Loss:       v.packetLoss,
+			RttMin:     v.minRtt,
+			RttMean:    v.meanRtt,
+			RttMax:     v.maxRtt,
+		}
+
+		if v.packetLoss >= 1 {
+			result.Error = ErrPacketLoss.Error()
+		}
+
+		for _, rtt := range v.rtts {
+			monitor.ObserveProbeICMPRtt(m.nodeName, v.network, v.ipFamily, v.targetZone, rtt)
+		}
+
+		results = append(results, result)
+	}
+
+	monitor.SetProbeResults(m.nodeName, m.probeName, results)
+
+	return nil
+}
+
+// NewTargetStats ...
+func NewTargetStats(probeName, nodeName string) *targetStats {
+	return &targetStats{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+// AddTargetStat ...
+func (i *icmpProbe) AddTargetStat(target Target, stat PingStat) {
+	i.Lock()
+	defer i.Unlock()
+
+	v := targetStat{
+		target:       target.IP,
+		targetNode:   target.Node,
+		targetZone:   target.Zone,
+		targetRegion: target.Region,
+		network:      string(target.Network),
+		ipFamily:     string(target.Family),
+		minRtt:       float64(stat.Best.Microseconds()),
+		meanRtt:      float64(stat.Mean.Microseconds()),
+		maxRtt:       float64(stat.Worst.Microseconds()),
+		packetLoss:   stat.Loss,
+		duplicates:   stat.Duplicates,
+		reordered:    stat.Reordered,
+	}
+
+	for _, p := range stat.Packets {
+		if rtt := p.RTT(); rtt > 0 {
+			v.rtts = append(v.rtts, float64(rtt.Microseconds()))
+		}
+	}
+
+	i.targetStats.values = append(i.targetStats.values, v)
+	i.zoneStats.values = append(i.zoneStats.values, v)
+}
+
 // AddMaxRtt ...
 func (i *icmpProbe) AddMaxRtt(value float64) {
 	i.Lock()
@@ -282,6 +392,11 @@ func (m *reportNumber) Collect(ch chan<- Metric) {
 	ch <- m
 }
 
+// Collect ...
+func (m *targetStats) Collect(ch chan<- Metric) {
+	ch <- m
+}
+
 type icmpProbe struct {
 	opts *Opts
 
@@ -294,10 +409,14 @@ type icmpProbe struct {
 	totalNumber  *totalNumber
 	reportNumber *reportNumber
 	packetLoss   *packetLoss
+	targetStats  *targetStats
+	zoneStats    *zoneStats
 
+	source          Topology
 	timeout         time.Duration
 	count           int
 	reportThreshold float64
+	socket          v1alpha1.ICMPSocket
 
 	Collector
 	sync.RWMutex
@@ -326,6 +445,8 @@ func (i *icmpProbe) configure(c *v1alpha1.Config) error {
 		i.count = c.ICMP.Count
 	}
 
+	i.socket = c.ICMP.Socket
+
 	return nil
 }
 
@@ -361,6 +482,8 @@ func (p *icmpProbe) Reset() {
 	p.packetLoss = NewPacketLoss(p.name, p.nodeName)
 	p.reportNumber = NewReportNumber(p.name, p.nodeName)
 	p.totalNumber = NewTotalNumber(p.name, p.nodeName)
+	p.targetStats = NewTargetStats(p.name, p.nodeName)
+	p.zoneStats = NewZoneStats(p.name, p.source, p.reportThreshold)
 }
 
 // Collect ...
@@ -371,6 +494,8 @@ func (i *icmpProbe) Collect(ch chan<- Metric) {
 	i.packetLoss.Collect(ch)
 	i.reportNumber.Collect(ch)
 	i.totalNumber.Collect(ch)
+	i.targetStats.Collect(ch)
+	i.zoneStats.Collect(ch)
 }
 
 // Do ...
@@ -381,7 +506,7 @@ func (i *icmpProbe) Do(ctx context.Context, metrics Gatherer) func() error {
 			return err
 		}
 
-		ticker := time.NewTicker(1 * time.Second)
+		ticker := NewSplayTicker(i.opts.interval)
 		defer ticker.Stop()
 
 		loaders := []NodeLoader{
@@ -389,47 +514,71 @@ func (i *icmpProbe) Do(ctx context.Context, metrics Gatherer) func() error {
 		}
 
 		filters := []NodeFilter{
-			FilterIP(i.opts.hostIP),
-			FilterIP(i.opts.podIP),
+			FilterNode(i.nodeName),
+			FilterIP(i.opts.ownIPs()...),
+			FilterNetwork(i.opts.config.Network),
+			FilterIPFamily(i.opts.ipFamilies...),
 		}
 
 		nodeList := NewNodeList(loaders, filters...)
+		allNodes := NewNodeList(loaders)
 
 		for {
 			select {
 			case <-ctx.Done():
+				return nil
 			case <-ticker.C:
+				all, err := allNodes.Load()
+				if err != nil {
+					return err
+				}
+				i.source = topologyOf(all, i.nodeName)
+
 				nodes, err := nodeList.Load()
 				if err != nil {
 					return err
 				}
 
-				opt := pinger.DefaultICMPPingOpts()
-				opt.Interval = func() time.Duration { return 100 * time.Millisecond }
-				opt.PingCount = i.count
-				opt.PingTimeout = i.timeout
+				hosts := make([]string, 0, len(nodes))
+				targets := make(map[string]Target, len(nodes))
+				for _, node := range nodes {
+					hosts = append(hosts, node.IP)
+					targets[node.IP] = node
+				}
+
+				opts := PingOpts{
+					Count:   i.count,
+					Timeout: i.timeout,
+					Socket:  i.socket,
+				}
 
 				i.Reset()
 				i.SetTotalNumber(float64(len(nodes)))
 
-				stats, err := pinger.ICMPPing(opt, nodes...)
+				stats, err := Ping(ctx, opts, hosts...)
 				if err != nil {
 					return err
 				}
 
 				for _, stat := range stats {
-					if stat.PktLossRate < i.reportThreshold {
+					if stat.Loss < i.reportThreshold {
 						i.IncReportNumber()
 					}
 
 					i.AddMaxRtt(float64(stat.Worst.Microseconds()))
 					i.AddMinRtt(float64(stat.Best.Microseconds()))
 					i.AddMeanRtt(float64(stat.Mean.Microseconds()))
-					i.AddPacketLoss(float64(stat.PktLossRate))
+					i.AddPacketLoss(stat.Loss)
+
+					target, ok := targets[stat.Host]
+					if !ok {
+						target = Target{IP: stat.Host}
+					}
+					i.AddTargetStat(target, stat)
 				}
 
 				metrics.Gather(i)
-				ticker.Reset(1 * time.Second)
+				ticker.Reset()
 
 				continue
 			}
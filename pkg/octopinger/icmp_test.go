@@ -3,6 +3,8 @@ package octopinger
 import (
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,3 +28,38 @@ func TestNewMeanRtt(t *testing.T) {
 	assert.Equal(t, maxRtt.nodeName, "monalisa")
 	assert.Equal(t, maxRtt.probeName, "icmp")
 }
+
+func TestNewTargetStats(t *testing.T) {
+	targetStats := NewTargetStats("icmp", "monalisa")
+
+	assert.Equal(t, targetStats.nodeName, "monalisa")
+	assert.Equal(t, targetStats.probeName, "icmp")
+	assert.Empty(t, targetStats.values)
+}
+
+func TestTargetStatsWrite(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	stats := NewTargetStats("icmp", "monalisa")
+	stats.values = []targetStat{
+		{target: "10.0.0.2", network: "host", ipFamily: "IPv4", targetZone: "de-fra-1", meanRtt: 200, packetLoss: 0.25, rtts: []float64{100, 200, 300}},
+		{target: "10.0.0.3", network: "host", ipFamily: "IPv4", targetZone: "de-fra-1", packetLoss: 1},
+	}
+
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_icmp_rtt_seconds"))
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m)
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	for _, f := range families {
+		if f.GetName() == "octopinger_probe_icmp_rtt_seconds" {
+			assert.Equal(t, uint64(3), f.GetMetric()[0].GetHistogram().GetSampleCount())
+		}
+	}
+}
@@ -2,7 +2,10 @@ package octopinger
 
 import (
 	"net/http"
+	"slices"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -43,145 +46,972 @@ var DefaultMetrics = NewMetrics()
 
 // Metrics ...
 type Metrics struct {
-	probeRttMax          *prometheus.GaugeVec
-	probeRttMin          *prometheus.GaugeVec
-	probeRttMean         *prometheus.GaugeVec
-	probePacketLossMin   *prometheus.GaugeVec
-	probePacketLossMax   *prometheus.GaugeVec
-	probePacketLossMean  *prometheus.GaugeVec
-	probePacketLossTotal *prometheus.GaugeVec
-	probeNodesTotal      *prometheus.GaugeVec
-	probeNodesReports    *prometheus.GaugeVec
-	probeDNSSuccess      *prometheus.GaugeVec
-	probeDNSError        *prometheus.GaugeVec
+	probeRttMax             *prometheus.GaugeVec
+	probeRttMin             *prometheus.GaugeVec
+	probeRttMean            *prometheus.GaugeVec
+	probePacketLossMin      *prometheus.GaugeVec
+	probePacketLossMax      *prometheus.GaugeVec
+	probePacketLossMean     *prometheus.GaugeVec
+	probePacketLossTotal    *prometheus.GaugeVec
+	probeNodesTotal         *prometheus.GaugeVec
+	probeNodesReports       *prometheus.GaugeVec
+	probeDNSSuccess         *prometheus.GaugeVec
+	probeDNSError           *prometheus.GaugeVec
+	probeDNSEndpointSuccess *prometheus.GaugeVec
+	probeDNSEndpointError   *prometheus.GaugeVec
+	probeDNSEndpointTime    *prometheus.GaugeVec
+	probeDNSCheckTime       *prometheus.GaugeVec
+	probeDNSCheckAnswers    *prometheus.GaugeVec
+	probeDNSCheckSuccess    *prometheus.CounterVec
+	probeDNSCheckError      *prometheus.CounterVec
+	probeDNSUpstreamTime    *prometheus.GaugeVec
+	probeDNSUpstreamSuccess *prometheus.CounterVec
+	probeDNSUpstreamError   *prometheus.CounterVec
+	probeDNSSearchQueries   *prometheus.GaugeVec
+	probeDNSSearchTime      *prometheus.GaugeVec
+	probeDNSSearchDomain    *prometheus.GaugeVec
+	probeDNSSearchSuccess   *prometheus.CounterVec
+	probeDNSSearchError     *prometheus.CounterVec
+	probeTargetRttMin       *prometheus.GaugeVec
+	probeTargetRttMean      *prometheus.GaugeVec
+	probeTargetRttMax       *prometheus.GaugeVec
+	probeTargetLoss         *prometheus.GaugeVec
+	probeTargetDuplicates   *prometheus.GaugeVec
+	probeTargetReordered    *prometheus.GaugeVec
+	probeZoneLoss           *prometheus.GaugeVec
+	probeZoneRttMean        *prometheus.GaugeVec
+	probeZoneRttMax         *prometheus.GaugeVec
+	probeZoneDegraded       *prometheus.GaugeVec
+	probeTCPConnectTime     *prometheus.GaugeVec
+	probeTCPSuccess         *prometheus.CounterVec
+	probeTCPError           *prometheus.CounterVec
+	probeHTTPDNSTime        *prometheus.GaugeVec
+	probeHTTPConnectTime    *prometheus.GaugeVec
+	probeHTTPTLSTime        *prometheus.GaugeVec
+	probeHTTPTTFB           *prometheus.GaugeVec
+	probeHTTPTotalTime      *prometheus.GaugeVec
+	probeHTTPStatusCode     *prometheus.GaugeVec
+	probeHTTPSuccess        *prometheus.CounterVec
+	probeHTTPError          *prometheus.CounterVec
+	probeTLSCertExpiryDays  *prometheus.GaugeVec
+	probeTLSChainExpiryDays *prometheus.GaugeVec
+	probeTLSChainValid      *prometheus.GaugeVec
+	probeTLSSNIMismatch     *prometheus.GaugeVec
+	probeTLSInfo            *prometheus.GaugeVec
+	probeTLSError           *prometheus.CounterVec
+	probeServiceTime        *prometheus.GaugeVec
+	probeServiceSuccess     *prometheus.CounterVec
+	probeServiceError       *prometheus.CounterVec
+	probeMTUSizeSuccess     *prometheus.GaugeVec
+	probeMTUPath            *prometheus.GaugeVec
+	probeMTUBelowExpected   *prometheus.GaugeVec
+
+	histograms    *histograms
+	histogramsMux sync.RWMutex
+}
+
+// DefaultLatencyBuckets are exponential buckets from 100µs to about 1.6s,
+// which resolve the sub-millisecond round-trip times within a cluster.
+var DefaultLatencyBuckets = prometheus.ExponentialBuckets(0.0001, 2, 15)
+
+// histograms are the latency distributions of the probes in seconds.
+type histograms struct {
+	buckets []float64
+	native  bool
+
+	probeICMPRtt          *prometheus.HistogramVec
+	probeDNSLookupTime    *prometheus.HistogramVec
+	probeDNSCheckTime     *prometheus.HistogramVec
+	probeDNSUpstreamTime  *prometheus.HistogramVec
+	probeDNSSearchTime    *prometheus.HistogramVec
+	probeTCPConnectTime   *prometheus.HistogramVec
+	probeHTTPTotalTime    *prometheus.HistogramVec
+	probeServiceTotalTime *prometheus.HistogramVec
+}
+
+func newHistograms(buckets []float64, native bool) *histograms {
+	h := &histograms{buckets: buckets, native: native}
+
+	opts := func(name, help string) prometheus.HistogramOpts {
+		o := prometheus.HistogramOpts{
+			Name:    name,
+			Help:    help,
+			Buckets: buckets,
+		}
+
+		if native {
+			o.NativeHistogramBucketFactor = 1.1
+			o.NativeHistogramMaxBucketNumber = 100
+			o.NativeHistogramMinResetDuration = time.Hour
+		}
+
+		return o
+	}
+
+	h.probeICMPRtt = prometheus.NewHistogramVec(
+		opts("octopinger_probe_icmp_rtt_seconds", "Distribution of the round-trip time of the echo replies of the ICMP probe."),
+		[]string{
+			"octopinger_node",
+			"octopinger_network",
+			"octopinger_ip_family",
+			"octopinger_target_zone",
+		},
+	)
+
+	h.probeDNSLookupTime = prometheus.NewHistogramVec(
+		opts("octopinger_probe_dns_lookup_seconds", "Distribution of the time of successful DNS lookups."),
+		[]string{
+			"octopinger_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	h.probeDNSCheckTime = prometheus.NewHistogramVec(
+		opts("octopinger_probe_dns_check_seconds", "Distribution of the time of successful DNS checks."),
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_record_type",
+			"octopinger_dns_server",
+		},
+	)
+
+	h.probeDNSUpstreamTime = prometheus.NewHistogramVec(
+		opts("octopinger_probe_dns_upstream_seconds", "Distribution of the time of successful lookups at an encrypted DNS server."),
+		[]string{
+			"octopinger_node",
+			"octopinger_dns_server",
+			"octopinger_dns_transport",
+			"octopinger_ip_family",
+		},
+	)
+
+	h.probeDNSSearchTime = prometheus.NewHistogramVec(
+		opts("octopinger_probe_dns_search_seconds", "Distribution of the total time of successful lookups through the search list."),
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	h.probeTCPConnectTime = prometheus.NewHistogramVec(
+		opts("octopinger_probe_tcp_connect_seconds", "Distribution of the time to establish a TCP connection to a target."),
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_ip_family",
+		},
+	)
+
+	h.probeHTTPTotalTime = prometheus.NewHistogramVec(
+		opts("octopinger_probe_http_total_seconds", "Distribution of the total time of successful requests to a HTTP target."),
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	h.probeServiceTotalTime = prometheus.NewHistogramVec(
+		opts("octopinger_probe_service_total_seconds", "Distribution of the time of successful requests to the Service."),
+		[]string{
+			"octopinger_node",
+			"octopinger_service_path",
+		},
+	)
+
+	return h
 }
 
-// NewMetrics ...
-func NewMetrics() *Metrics {
-	m := new(Metrics)
+func (h *histograms) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		h.probeICMPRtt,
+		h.probeDNSLookupTime,
+		h.probeDNSCheckTime,
+		h.probeDNSUpstreamTime,
+		h.probeDNSSearchTime,
+		h.probeTCPConnectTime,
+		h.probeHTTPTotalTime,
+		h.probeServiceTotalTime,
+	}
+}
+
+// NewMetrics ...
+func NewMetrics() *Metrics {
+	m := new(Metrics)
+
+	m.probeDNSSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_success",
+			Help: "Number of successful probed DNS records.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeDNSError = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_error",
+			Help: "Number of errored probed DNS records.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeDNSEndpointSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_endpoint_success",
+			Help: "Number of successful probed DNS records at an endpoint of the cluster DNS Service.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_dns_endpoint",
+			"octopinger_dns_server",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeDNSEndpointError = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_endpoint_error",
+			Help: "Number of errored probed DNS records at an endpoint of the cluster DNS Service.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_dns_endpoint",
+			"octopinger_dns_server",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeDNSEndpointTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_endpoint_time",
+			Help: "Mean time of the successful lookups at an endpoint of the cluster DNS Service.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_dns_endpoint",
+			"octopinger_dns_server",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeDNSCheckTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_check_time",
+			Help: "Time of the query of a DNS check.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_record_type",
+			"octopinger_dns_server",
+		},
+	)
+
+	m.probeDNSCheckAnswers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_check_answers",
+			Help: "Number of answers to the query of a DNS check.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_record_type",
+			"octopinger_dns_server",
+		},
+	)
+
+	m.probeDNSCheckSuccess = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_dns_check_success_total",
+			Help: "Number of successful DNS checks.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_record_type",
+			"octopinger_dns_server",
+		},
+	)
+
+	m.probeDNSCheckError = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_dns_check_error_total",
+			Help: "Number of failed DNS checks by error class.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_record_type",
+			"octopinger_dns_server",
+			"octopinger_error",
+		},
+	)
+
+	m.probeDNSUpstreamTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_upstream_time",
+			Help: "Mean time of the successful lookups at an encrypted DNS server.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_dns_server",
+			"octopinger_dns_transport",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeDNSUpstreamSuccess = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_dns_upstream_success_total",
+			Help: "Number of successful lookups at an encrypted DNS server.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_dns_server",
+			"octopinger_dns_transport",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeDNSUpstreamError = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_dns_upstream_error_total",
+			Help: "Number of failed lookups at an encrypted DNS server by error class.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_dns_server",
+			"octopinger_dns_transport",
+			"octopinger_ip_family",
+			"octopinger_error",
+		},
+	)
+
+	m.probeDNSSearchQueries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_search_queries",
+			Help: "Number of queries of the last lookup of a name through the search list.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	m.probeDNSSearchTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_search_time",
+			Help: "Total time of the last lookup of a name through the search list.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	m.probeDNSSearchDomain = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_dns_search_domain_time",
+			Help: "Mean time of the queries of the names with a search domain.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_search_domain",
+		},
+	)
+
+	m.probeDNSSearchSuccess = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_dns_search_success_total",
+			Help: "Number of successful lookups of a name through the search list.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	m.probeDNSSearchError = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_dns_search_error_total",
+			Help: "Number of failed lookups of a name through the search list by error class.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_error",
+		},
+	)
+
+	m.probeNodesTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_nodes_total",
+			Help: "Total number of probed nodes.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probeNodesReports = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_nodes_reports",
+			Help: "Number of nodes that reported results.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probeRttMin = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_rtt_min",
+			Help: "Min round-trip time of the probe in this instance.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probeRttMean = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_rtt_mean",
+			Help: "Mean round-trip time of the probe in this instance.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probeRttMax = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_rtt_max",
+			Help: "Max round-trip time of the probe in this instance.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probePacketLossMin = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_loss_min",
+			Help: "Min fraction of lost packets to the targets, from 0 to 1.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probePacketLossMax = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_loss_max",
+			Help: "Max fraction of lost packets to the targets, from 0 to 1.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probePacketLossMean = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_loss_mean",
+			Help: "Mean fraction of lost packets to the targets, from 0 to 1.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probePacketLossTotal = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_loss_total",
+			Help: "Sum of the fractions of lost packets to the targets.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+		},
+	)
+
+	m.probeTargetRttMin = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_target_rtt_min",
+			Help: "Min round-trip time of the probe to a target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_target_zone",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTargetRttMean = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_target_rtt_mean",
+			Help: "Mean round-trip time of the probe to a target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_target_zone",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTargetRttMax = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_target_rtt_max",
+			Help: "Max round-trip time of the probe to a target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_target_zone",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTargetLoss = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_target_loss",
+			Help: "Fraction of lost packets to a target, from 0 to 1.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_target_zone",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTargetDuplicates = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_target_duplicates",
+			Help: "Number of duplicate echo replies from a target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_target_zone",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTargetReordered = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_target_reordered",
+			Help: "Number of echo replies from a target that arrived out of order.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_target_zone",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeZoneLoss = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_zone_loss",
+			Help: "Mean fraction of lost packets from the zone of the node to a target zone, from 0 to 1.",
+		},
+		[]string{
+			"octopinger_probe",
+			"octopinger_zone",
+			"octopinger_region",
+			"octopinger_target_zone",
+			"octopinger_target_region",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeZoneRttMean = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_zone_rtt_mean",
+			Help: "Mean round-trip time from the zone of the node to a target zone.",
+		},
+		[]string{
+			"octopinger_probe",
+			"octopinger_zone",
+			"octopinger_region",
+			"octopinger_target_zone",
+			"octopinger_target_region",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeZoneRttMax = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_zone_rtt_max",
+			Help: "Max round-trip time from the zone of the node to a target zone.",
+		},
+		[]string{
+			"octopinger_probe",
+			"octopinger_zone",
+			"octopinger_region",
+			"octopinger_target_zone",
+			"octopinger_target_region",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeZoneDegraded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_zone_degraded",
+			Help: "Set if the link to another zone is degraded, while the link within the zone of the node is healthy.",
+		},
+		[]string{
+			"octopinger_probe",
+			"octopinger_zone",
+			"octopinger_region",
+			"octopinger_target_zone",
+			"octopinger_target_region",
+			"octopinger_target_network",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTCPConnectTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_tcp_connect_time",
+			Help: "Time to establish a TCP connection to a target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTCPSuccess = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_tcp_success_total",
+			Help: "Number of successful TCP connections to a target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeTCPError = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_tcp_error_total",
+			Help: "Number of failed TCP connections to a target by error class.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+			"octopinger_error",
+		},
+	)
+
+	m.probeHTTPDNSTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_http_dns_time",
+			Help: "Time to resolve the host of a HTTP target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	m.probeHTTPConnectTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_http_connect_time",
+			Help: "Time to connect to a HTTP target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	m.probeHTTPTLSTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_http_tls_time",
+			Help: "Time of the TLS handshake with a HTTP target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
 
-	m.probeDNSSuccess = prometheus.NewGaugeVec(
+	m.probeHTTPTTFB = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_dns_success",
-			Help: "Number of successful probed DNS records.",
+			Name: "octopinger_probe_http_ttfb",
+			Help: "Time to the first byte of the response of a HTTP target.",
 		},
 		[]string{
 			"octopinger_node",
+			"octopinger_target",
 		},
 	)
 
-	m.probeDNSError = prometheus.NewGaugeVec(
+	m.probeHTTPTotalTime = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_dns_error",
-			Help: "Number of errored probed DNS records.",
+			Name: "octopinger_probe_http_total_time",
+			Help: "Total time of the request to a HTTP target.",
 		},
 		[]string{
 			"octopinger_node",
+			"octopinger_target",
 		},
 	)
 
-	m.probeNodesTotal = prometheus.NewGaugeVec(
+	m.probeHTTPStatusCode = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_nodes_total",
-			Help: "Total number of probed nodes.",
+			Name: "octopinger_probe_http_status_code",
+			Help: "Status code of the response of a HTTP target.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
 		},
 	)
 
-	m.probeNodesReports = prometheus.NewGaugeVec(
+	m.probeHTTPSuccess = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_http_success_total",
+			Help: "Number of successful requests to a HTTP target.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+		},
+	)
+
+	m.probeHTTPError = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_http_error_total",
+			Help: "Number of failed requests to a HTTP target by error class.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_error",
+		},
+	)
+
+	m.probeTLSCertExpiryDays = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_nodes_reports",
-			Help: "Number of nodes that reported results.",
+			Name: "octopinger_probe_tls_cert_expiry_days",
+			Help: "Days until the certificate of a TLS target expires.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_server_name",
 		},
 	)
 
-	m.probeRttMin = prometheus.NewGaugeVec(
+	m.probeTLSChainExpiryDays = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_rtt_min",
-			Help: "Min round-trip time of the probe in this instance.",
+			Name: "octopinger_probe_tls_chain_expiry_days",
+			Help: "Days until the first certificate in the chain of a TLS target expires.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_server_name",
 		},
 	)
 
-	m.probeRttMean = prometheus.NewGaugeVec(
+	m.probeTLSChainValid = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_rtt_mean",
-			Help: "Mean round-trip time of the probe in this instance.",
+			Name: "octopinger_probe_tls_chain_valid",
+			Help: "Whether the certificate chain of a TLS target is valid.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_server_name",
 		},
 	)
 
-	m.probeRttMax = prometheus.NewGaugeVec(
+	m.probeTLSSNIMismatch = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_rtt_max",
-			Help: "Max round-trip time of the probe in this instance.",
+			Name: "octopinger_probe_tls_sni_mismatch",
+			Help: "Whether the certificate of a TLS target does not match the server name.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_server_name",
 		},
 	)
 
-	m.probePacketLossMin = prometheus.NewGaugeVec(
+	m.probeTLSInfo = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_loss_min",
-			Help: "Min percentage of lost packets.",
+			Name: "octopinger_probe_tls_info",
+			Help: "Negotiated protocol version and cipher suite of a TLS target.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_server_name",
+			"octopinger_tls_version",
+			"octopinger_tls_cipher",
 		},
 	)
 
-	m.probePacketLossMax = prometheus.NewGaugeVec(
+	m.probeTLSError = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_tls_error_total",
+			Help: "Number of failed handshakes with a TLS target by error class.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_server_name",
+			"octopinger_error",
+		},
+	)
+
+	m.probeServiceTime = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_loss_max",
-			Help: "Max percentage of lost packets.",
+			Name: "octopinger_probe_service_time",
+			Help: "Time of the request to the Service.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_service_path",
 		},
 	)
 
-	m.probePacketLossMean = prometheus.NewGaugeVec(
+	m.probeServiceSuccess = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_service_success_total",
+			Help: "Number of successful requests to the Service by answering backend.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_service_path",
+			"octopinger_backend_node",
+		},
+	)
+
+	m.probeServiceError = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "octopinger_probe_service_error_total",
+			Help: "Number of failed requests to the Service by error class.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_service_path",
+			"octopinger_error",
+		},
+	)
+
+	m.probeMTUSizeSuccess = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_loss_mean",
-			Help: "Mean percentage of lost packets.",
+			Name: "octopinger_probe_mtu_size_success",
+			Help: "Set if an echo request of the size with the don't fragment bit reached a target.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+			"octopinger_size",
 		},
 	)
 
-	m.probePacketLossTotal = prometheus.NewGaugeVec(
+	m.probeMTUPath = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "octopinger_probe_loss_total",
-			Help: "Total packet loss on a node.",
+			Name: "octopinger_probe_mtu_path",
+			Help: "Largest packet size that reached a target, up to the expected MTU.",
 		},
 		[]string{
 			"octopinger_node",
-			"octopinger_probe",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_ip_family",
+		},
+	)
+
+	m.probeMTUBelowExpected = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "octopinger_probe_mtu_below_expected",
+			Help: "Set if the path MTU to a target is below the expected MTU.",
+		},
+		[]string{
+			"octopinger_node",
+			"octopinger_target",
+			"octopinger_target_node",
+			"octopinger_ip_family",
 		},
 	)
 
+	m.histograms = newHistograms(DefaultLatencyBuckets, false)
+
 	return m
 }
 
+// ConfigureHistograms is replacing the histograms if the buckets have changed.
+// The distributions observed so far are dropped in that case.
+func (m *Metrics) ConfigureHistograms(buckets []float64, native bool) {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	m.histogramsMux.Lock()
+	defer m.histogramsMux.Unlock()
+
+	if slices.Equal(m.histograms.buckets, buckets) && m.histograms.native == native {
+		return
+	}
+
+	m.histograms = newHistograms(buckets, native)
+}
+
 // Collect ...
 func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
 	m.probeRttMax.Collect(ch)
@@ -195,6 +1025,61 @@ func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
 	m.probeNodesReports.Collect(ch)
 	m.probeDNSSuccess.Collect(ch)
 	m.probeDNSError.Collect(ch)
+	m.probeDNSEndpointSuccess.Collect(ch)
+	m.probeDNSEndpointError.Collect(ch)
+	m.probeDNSEndpointTime.Collect(ch)
+	m.probeDNSCheckTime.Collect(ch)
+	m.probeDNSCheckAnswers.Collect(ch)
+	m.probeDNSCheckSuccess.Collect(ch)
+	m.probeDNSCheckError.Collect(ch)
+	m.probeDNSUpstreamTime.Collect(ch)
+	m.probeDNSUpstreamSuccess.Collect(ch)
+	m.probeDNSUpstreamError.Collect(ch)
+	m.probeDNSSearchQueries.Collect(ch)
+	m.probeDNSSearchTime.Collect(ch)
+	m.probeDNSSearchDomain.Collect(ch)
+	m.probeDNSSearchSuccess.Collect(ch)
+	m.probeDNSSearchError.Collect(ch)
+	m.probeTargetRttMin.Collect(ch)
+	m.probeTargetRttMean.Collect(ch)
+	m.probeTargetRttMax.Collect(ch)
+	m.probeTargetLoss.Collect(ch)
+	m.probeTargetDuplicates.Collect(ch)
+	m.probeTargetReordered.Collect(ch)
+	m.probeZoneLoss.Collect(ch)
+	m.probeZoneRttMean.Collect(ch)
+	m.probeZoneRttMax.Collect(ch)
+	m.probeZoneDegraded.Collect(ch)
+	m.probeTCPConnectTime.Collect(ch)
+	m.probeTCPSuccess.Collect(ch)
+	m.probeTCPError.Collect(ch)
+	m.probeHTTPDNSTime.Collect(ch)
+	m.probeHTTPConnectTime.Collect(ch)
+	m.probeHTTPTLSTime.Collect(ch)
+	m.probeHTTPTTFB.Collect(ch)
+	m.probeHTTPTotalTime.Collect(ch)
+	m.probeHTTPStatusCode.Collect(ch)
+	m.probeHTTPSuccess.Collect(ch)
+	m.probeHTTPError.Collect(ch)
+	m.probeTLSCertExpiryDays.Collect(ch)
+	m.probeTLSChainExpiryDays.Collect(ch)
+	m.probeTLSChainValid.Collect(ch)
+	m.probeTLSSNIMismatch.Collect(ch)
+	m.probeTLSInfo.Collect(ch)
+	m.probeTLSError.Collect(ch)
+	m.probeServiceTime.Collect(ch)
+	m.probeServiceSuccess.Collect(ch)
+	m.probeServiceError.Collect(ch)
+	m.probeMTUSizeSuccess.Collect(ch)
+	m.probeMTUPath.Collect(ch)
+	m.probeMTUBelowExpected.Collect(ch)
+
+	m.histogramsMux.RLock()
+	defer m.histogramsMux.RUnlock()
+
+	for _, h := range m.histograms.collectors() {
+		h.Collect(ch)
+	}
 }
 
 // Describe ...
@@ -210,11 +1095,67 @@ func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
 	m.probeNodesReports.Describe(ch)
 	m.probeDNSSuccess.Describe(ch)
 	m.probeDNSError.Describe(ch)
+	m.probeDNSEndpointSuccess.Describe(ch)
+	m.probeDNSEndpointError.Describe(ch)
+	m.probeDNSEndpointTime.Describe(ch)
+	m.probeDNSCheckTime.Describe(ch)
+	m.probeDNSCheckAnswers.Describe(ch)
+	m.probeDNSCheckSuccess.Describe(ch)
+	m.probeDNSCheckError.Describe(ch)
+	m.probeDNSUpstreamTime.Describe(ch)
+	m.probeDNSUpstreamSuccess.Describe(ch)
+	m.probeDNSUpstreamError.Describe(ch)
+	m.probeDNSSearchQueries.Describe(ch)
+	m.probeDNSSearchTime.Describe(ch)
+	m.probeDNSSearchDomain.Describe(ch)
+	m.probeDNSSearchSuccess.Describe(ch)
+	m.probeDNSSearchError.Describe(ch)
+	m.probeTargetRttMin.Describe(ch)
+	m.probeTargetRttMean.Describe(ch)
+	m.probeTargetRttMax.Describe(ch)
+	m.probeTargetLoss.Describe(ch)
+	m.probeTargetDuplicates.Describe(ch)
+	m.probeTargetReordered.Describe(ch)
+	m.probeZoneLoss.Describe(ch)
+	m.probeZoneRttMean.Describe(ch)
+	m.probeZoneRttMax.Describe(ch)
+	m.probeZoneDegraded.Describe(ch)
+	m.probeTCPConnectTime.Describe(ch)
+	m.probeTCPSuccess.Describe(ch)
+	m.probeTCPError.Describe(ch)
+	m.probeHTTPDNSTime.Describe(ch)
+	m.probeHTTPConnectTime.Describe(ch)
+	m.probeHTTPTLSTime.Describe(ch)
+	m.probeHTTPTTFB.Describe(ch)
+	m.probeHTTPTotalTime.Describe(ch)
+	m.probeHTTPStatusCode.Describe(ch)
+	m.probeHTTPSuccess.Describe(ch)
+	m.probeHTTPError.Describe(ch)
+	m.probeTLSCertExpiryDays.Describe(ch)
+	m.probeTLSChainExpiryDays.Describe(ch)
+	m.probeTLSChainValid.Describe(ch)
+	m.probeTLSSNIMismatch.Describe(ch)
+	m.probeTLSInfo.Describe(ch)
+	m.probeTLSError.Describe(ch)
+	m.probeServiceTime.Describe(ch)
+	m.probeServiceSuccess.Describe(ch)
+	m.probeServiceError.Describe(ch)
+	m.probeMTUSizeSuccess.Describe(ch)
+	m.probeMTUPath.Describe(ch)
+	m.probeMTUBelowExpected.Describe(ch)
+
+	m.histogramsMux.RLock()
+	defer m.histogramsMux.RUnlock()
+
+	for _, h := range m.histograms.collectors() {
+		h.Describe(ch)
+	}
 }
 
 // Monitor ...
 type Monitor struct {
 	metrics *Metrics
+	results *Results
 
 	sync.Mutex
 }
@@ -229,6 +1170,7 @@ type Gatherer interface {
 func NewMonitor(metrics *Metrics) *Monitor {
 	m := new(Monitor)
 	m.metrics = metrics
+	m.results = NewResults()
 
 	return m
 }
@@ -250,6 +1192,16 @@ func (m *Monitor) Gather(collector Collector) {
 	collector.Collect(ch)
 }
 
+// Results returns the latest results of the probes.
+func (m *Monitor) Results() *Results {
+	return m.results
+}
+
+// SetProbeResults ...
+func (m *Monitor) SetProbeResults(instance, probe string, results []Result) {
+	m.results.Set(instance, probe, results)
+}
+
 // SetProbeNodesTotal ...
 func (m *Monitor) SetProbeNodesTotal(instance, probe string, num float64) {
 	m.metrics.probeNodesTotal.WithLabelValues(instance, probe).Set(num)
@@ -276,18 +1228,18 @@ func (m *Monitor) SetProbeRttMean(instance, probe string, rtt float64) {
 }
 
 // SetProbePacketLossMin ...
-func (m *Monitor) SetProbePacketLossMin(instance, probe string, percentage float64) {
-	m.metrics.probePacketLossMin.WithLabelValues(instance, probe).Set(percentage)
+func (m *Monitor) SetProbePacketLossMin(instance, probe string, fraction float64) {
+	m.metrics.probePacketLossMin.WithLabelValues(instance, probe).Set(fraction)
 }
 
 // SetProbePacketLossMax ...
-func (m *Monitor) SetProbePacketLossMax(instance, probe string, percentage float64) {
-	m.metrics.probePacketLossMax.WithLabelValues(instance, probe).Set(percentage)
+func (m *Monitor) SetProbePacketLossMax(instance, probe string, fraction float64) {
+	m.metrics.probePacketLossMax.WithLabelValues(instance, probe).Set(fraction)
 }
 
 // SetProbePacketLossMean ...
-func (m *Monitor) SetProbePacketLossMean(instance, probe string, percentage float64) {
-	m.metrics.probePacketLossMax.WithLabelValues(instance, probe).Set(percentage)
+func (m *Monitor) SetProbePacketLossMean(instance, probe string, fraction float64) {
+	m.metrics.probePacketLossMean.WithLabelValues(instance, probe).Set(fraction)
 }
 
 // SetProbePacketLossTotal ...
@@ -296,11 +1248,435 @@ func (m *Monitor) SetProbePacketLossTotal(instance, probe string, total float64)
 }
 
 // SetProbeDNSError ...
-func (m *Monitor) SetProbeDNSError(instance string, float float64) {
-	m.metrics.probeDNSError.WithLabelValues(instance).Set(float)
+func (m *Monitor) SetProbeDNSError(instance, family string, float float64) {
+	m.metrics.probeDNSError.WithLabelValues(instance, family).Set(float)
 }
 
 // SetProbeDNSSuccess ...
-func (m *Monitor) SetProbeDNSSuccess(instance string, float float64) {
-	m.metrics.probeDNSSuccess.WithLabelValues(instance).Set(float)
+func (m *Monitor) SetProbeDNSSuccess(instance, family string, float float64) {
+	m.metrics.probeDNSSuccess.WithLabelValues(instance, family).Set(float)
+}
+
+// ResetProbeDNSEndpoints removes the series of the endpoints, which can change between the rounds.
+func (m *Monitor) ResetProbeDNSEndpoints(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeDNSEndpointSuccess.DeletePartialMatch(labels)
+	m.metrics.probeDNSEndpointError.DeletePartialMatch(labels)
+	m.metrics.probeDNSEndpointTime.DeletePartialMatch(labels)
+}
+
+// SetProbeDNSEndpointSuccess ...
+func (m *Monitor) SetProbeDNSEndpointSuccess(instance, endpoint, server, endpointNode, family string, float float64) {
+	m.metrics.probeDNSEndpointSuccess.WithLabelValues(instance, endpoint, server, endpointNode, family).Set(float)
+}
+
+// SetProbeDNSEndpointError ...
+func (m *Monitor) SetProbeDNSEndpointError(instance, endpoint, server, endpointNode, family string, float float64) {
+	m.metrics.probeDNSEndpointError.WithLabelValues(instance, endpoint, server, endpointNode, family).Set(float)
+}
+
+// SetProbeDNSEndpointTime ...
+func (m *Monitor) SetProbeDNSEndpointTime(instance, endpoint, server, endpointNode, family string, duration float64) {
+	m.metrics.probeDNSEndpointTime.WithLabelValues(instance, endpoint, server, endpointNode, family).Set(duration)
+}
+
+// ResetProbeDNSChecks removes the gauges of all DNS checks in this instance.
+func (m *Monitor) ResetProbeDNSChecks(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeDNSCheckTime.DeletePartialMatch(labels)
+	m.metrics.probeDNSCheckAnswers.DeletePartialMatch(labels)
+}
+
+// SetProbeDNSCheckTime ...
+func (m *Monitor) SetProbeDNSCheckTime(instance, name, recordType, server string, duration float64) {
+	m.metrics.probeDNSCheckTime.WithLabelValues(instance, name, recordType, server).Set(duration)
+}
+
+// SetProbeDNSCheckAnswers ...
+func (m *Monitor) SetProbeDNSCheckAnswers(instance, name, recordType, server string, answers float64) {
+	m.metrics.probeDNSCheckAnswers.WithLabelValues(instance, name, recordType, server).Set(answers)
+}
+
+// IncProbeDNSCheckSuccess ...
+func (m *Monitor) IncProbeDNSCheckSuccess(instance, name, recordType, server string) {
+	m.metrics.probeDNSCheckSuccess.WithLabelValues(instance, name, recordType, server).Inc()
+}
+
+// IncProbeDNSCheckError ...
+func (m *Monitor) IncProbeDNSCheckError(instance, name, recordType, server, class string) {
+	m.metrics.probeDNSCheckError.WithLabelValues(instance, name, recordType, server, class).Inc()
+}
+
+// SetProbeDNSUpstreamTime ...
+func (m *Monitor) SetProbeDNSUpstreamTime(instance, server, transport, family string, duration float64) {
+	m.metrics.probeDNSUpstreamTime.WithLabelValues(instance, server, transport, family).Set(duration)
+}
+
+// IncProbeDNSUpstreamSuccess ...
+func (m *Monitor) IncProbeDNSUpstreamSuccess(instance, server, transport, family string) {
+	m.metrics.probeDNSUpstreamSuccess.WithLabelValues(instance, server, transport, family).Inc()
+}
+
+// IncProbeDNSUpstreamError ...
+func (m *Monitor) IncProbeDNSUpstreamError(instance, server, transport, family, class string) {
+	m.metrics.probeDNSUpstreamError.WithLabelValues(instance, server, transport, family, class).Inc()
+}
+
+// ResetProbeDNSSearch removes the gauges of the search list probe in this instance, which follow the resolv.conf.
+func (m *Monitor) ResetProbeDNSSearch(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeDNSSearchQueries.DeletePartialMatch(labels)
+	m.metrics.probeDNSSearchTime.DeletePartialMatch(labels)
+	m.metrics.probeDNSSearchDomain.DeletePartialMatch(labels)
+}
+
+// SetProbeDNSSearchQueries ...
+func (m *Monitor) SetProbeDNSSearchQueries(instance, name string, queries float64) {
+	m.metrics.probeDNSSearchQueries.WithLabelValues(instance, name).Set(queries)
+}
+
+// SetProbeDNSSearchTime ...
+func (m *Monitor) SetProbeDNSSearchTime(instance, name string, duration float64) {
+	m.metrics.probeDNSSearchTime.WithLabelValues(instance, name).Set(duration)
+}
+
+// SetProbeDNSSearchDomainTime ...
+func (m *Monitor) SetProbeDNSSearchDomainTime(instance, domain string, duration float64) {
+	m.metrics.probeDNSSearchDomain.WithLabelValues(instance, domain).Set(duration)
+}
+
+// IncProbeDNSSearchSuccess ...
+func (m *Monitor) IncProbeDNSSearchSuccess(instance, name string) {
+	m.metrics.probeDNSSearchSuccess.WithLabelValues(instance, name).Inc()
+}
+
+// IncProbeDNSSearchError ...
+func (m *Monitor) IncProbeDNSSearchError(instance, name, class string) {
+	m.metrics.probeDNSSearchError.WithLabelValues(instance, name, class).Inc()
+}
+
+// ResetProbeTargets removes the series of all targets of a probe in this instance.
+func (m *Monitor) ResetProbeTargets(instance, probe string) {
+	labels := prometheus.Labels{"octopinger_node": instance, "octopinger_probe": probe}
+
+	m.metrics.probeTargetRttMin.DeletePartialMatch(labels)
+	m.metrics.probeTargetRttMean.DeletePartialMatch(labels)
+	m.metrics.probeTargetRttMax.DeletePartialMatch(labels)
+	m.metrics.probeTargetLoss.DeletePartialMatch(labels)
+	m.metrics.probeTargetDuplicates.DeletePartialMatch(labels)
+	m.metrics.probeTargetReordered.DeletePartialMatch(labels)
+}
+
+// ResetProbeZones removes the series of all zones of a probe.
+func (m *Monitor) ResetProbeZones(probe string) {
+	labels := prometheus.Labels{"octopinger_probe": probe}
+
+	m.metrics.probeZoneLoss.DeletePartialMatch(labels)
+	m.metrics.probeZoneRttMean.DeletePartialMatch(labels)
+	m.metrics.probeZoneRttMax.DeletePartialMatch(labels)
+	m.metrics.probeZoneDegraded.DeletePartialMatch(labels)
+}
+
+// SetProbeZoneLoss ...
+func (m *Monitor) SetProbeZoneLoss(probe string, link ZoneLink, fraction float64) {
+	m.metrics.probeZoneLoss.WithLabelValues(link.labelValues(probe)...).Set(fraction)
+}
+
+// SetProbeZoneRttMean ...
+func (m *Monitor) SetProbeZoneRttMean(probe string, link ZoneLink, rtt float64) {
+	m.metrics.probeZoneRttMean.WithLabelValues(link.labelValues(probe)...).Set(rtt)
+}
+
+// SetProbeZoneRttMax ...
+func (m *Monitor) SetProbeZoneRttMax(probe string, link ZoneLink, rtt float64) {
+	m.metrics.probeZoneRttMax.WithLabelValues(link.labelValues(probe)...).Set(rtt)
+}
+
+// SetProbeZoneDegraded ...
+func (m *Monitor) SetProbeZoneDegraded(probe string, link ZoneLink, degraded bool) {
+	m.metrics.probeZoneDegraded.WithLabelValues(link.labelValues(probe)...).Set(boolToFloat(degraded))
+}
+
+// SetProbeTargetRttMin ...
+func (m *Monitor) SetProbeTargetRttMin(instance, probe, target, targetNode, targetZone, network, family string, rtt float64) {
+	m.metrics.probeTargetRttMin.WithLabelValues(instance, probe, target, targetNode, targetZone, network, family).Set(rtt)
+}
+
+// SetProbeTargetRttMean ...
+func (m *Monitor) SetProbeTargetRttMean(instance, probe, target, targetNode, targetZone, network, family string, rtt float64) {
+	m.metrics.probeTargetRttMean.WithLabelValues(instance, probe, target, targetNode, targetZone, network, family).Set(rtt)
+}
+
+// SetProbeTargetRttMax ...
+func (m *Monitor) SetProbeTargetRttMax(instance, probe, target, targetNode, targetZone, network, family string, rtt float64) {
+	m.metrics.probeTargetRttMax.WithLabelValues(instance, probe, target, targetNode, targetZone, network, family).Set(rtt)
+}
+
+// SetProbeTargetLoss ...
+func (m *Monitor) SetProbeTargetLoss(instance, probe, target, targetNode, targetZone, network, family string, fraction float64) {
+	m.metrics.probeTargetLoss.WithLabelValues(instance, probe, target, targetNode, targetZone, network, family).Set(fraction)
+}
+
+// SetProbeTargetDuplicates ...
+func (m *Monitor) SetProbeTargetDuplicates(instance, probe, target, targetNode, targetZone, network, family string, duplicates float64) {
+	m.metrics.probeTargetDuplicates.WithLabelValues(instance, probe, target, targetNode, targetZone, network, family).Set(duplicates)
+}
+
+// SetProbeTargetReordered ...
+func (m *Monitor) SetProbeTargetReordered(instance, probe, target, targetNode, targetZone, network, family string, reordered float64) {
+	m.metrics.probeTargetReordered.WithLabelValues(instance, probe, target, targetNode, targetZone, network, family).Set(reordered)
+}
+
+// ResetProbeTCP removes the gauges of all TCP targets in this instance,
+// so that failed and removed targets do not keep the time of their last connect.
+func (m *Monitor) ResetProbeTCP(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeTCPConnectTime.DeletePartialMatch(labels)
+}
+
+// SetProbeTCPConnectTime ...
+func (m *Monitor) SetProbeTCPConnectTime(instance, target, targetNode, family string, connectTime float64) {
+	m.metrics.probeTCPConnectTime.WithLabelValues(instance, target, targetNode, family).Set(connectTime)
+}
+
+// IncProbeTCPSuccess ...
+func (m *Monitor) IncProbeTCPSuccess(instance, target, targetNode, family string) {
+	m.metrics.probeTCPSuccess.WithLabelValues(instance, target, targetNode, family).Inc()
+}
+
+// IncProbeTCPError ...
+func (m *Monitor) IncProbeTCPError(instance, target, targetNode, family, class string) {
+	m.metrics.probeTCPError.WithLabelValues(instance, target, targetNode, family, class).Inc()
+}
+
+// ResetProbeHTTP removes the gauges of all HTTP targets in this instance,
+// so that failed and removed targets do not keep the values of their last request.
+func (m *Monitor) ResetProbeHTTP(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeHTTPDNSTime.DeletePartialMatch(labels)
+	m.metrics.probeHTTPConnectTime.DeletePartialMatch(labels)
+	m.metrics.probeHTTPTLSTime.DeletePartialMatch(labels)
+	m.metrics.probeHTTPTTFB.DeletePartialMatch(labels)
+	m.metrics.probeHTTPTotalTime.DeletePartialMatch(labels)
+	m.metrics.probeHTTPStatusCode.DeletePartialMatch(labels)
+}
+
+// SetProbeHTTPDNSTime ...
+func (m *Monitor) SetProbeHTTPDNSTime(instance, target string, duration float64) {
+	m.metrics.probeHTTPDNSTime.WithLabelValues(instance, target).Set(duration)
+}
+
+// SetProbeHTTPConnectTime ...
+func (m *Monitor) SetProbeHTTPConnectTime(instance, target string, duration float64) {
+	m.metrics.probeHTTPConnectTime.WithLabelValues(instance, target).Set(duration)
+}
+
+// SetProbeHTTPTLSTime ...
+func (m *Monitor) SetProbeHTTPTLSTime(instance, target string, duration float64) {
+	m.metrics.probeHTTPTLSTime.WithLabelValues(instance, target).Set(duration)
+}
+
+// SetProbeHTTPTTFB ...
+func (m *Monitor) SetProbeHTTPTTFB(instance, target string, duration float64) {
+	m.metrics.probeHTTPTTFB.WithLabelValues(instance, target).Set(duration)
+}
+
+// SetProbeHTTPTotalTime ...
+func (m *Monitor) SetProbeHTTPTotalTime(instance, target string, duration float64) {
+	m.metrics.probeHTTPTotalTime.WithLabelValues(instance, target).Set(duration)
+}
+
+// SetProbeHTTPStatusCode ...
+func (m *Monitor) SetProbeHTTPStatusCode(instance, target string, code float64) {
+	m.metrics.probeHTTPStatusCode.WithLabelValues(instance, target).Set(code)
+}
+
+// IncProbeHTTPSuccess ...
+func (m *Monitor) IncProbeHTTPSuccess(instance, target string) {
+	m.metrics.probeHTTPSuccess.WithLabelValues(instance, target).Inc()
+}
+
+// IncProbeHTTPError ...
+func (m *Monitor) IncProbeHTTPError(instance, target, class string) {
+	m.metrics.probeHTTPError.WithLabelValues(instance, target, class).Inc()
+}
+
+// ResetProbeTLS removes the gauges of all TLS targets in this instance,
+// so that failed and removed targets do not keep the values of their last handshake.
+func (m *Monitor) ResetProbeTLS(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeTLSCertExpiryDays.DeletePartialMatch(labels)
+	m.metrics.probeTLSChainExpiryDays.DeletePartialMatch(labels)
+	m.metrics.probeTLSChainValid.DeletePartialMatch(labels)
+	m.metrics.probeTLSSNIMismatch.DeletePartialMatch(labels)
+	m.metrics.probeTLSInfo.DeletePartialMatch(labels)
+}
+
+// SetProbeTLSCertExpiryDays ...
+func (m *Monitor) SetProbeTLSCertExpiryDays(instance, target, serverName string, days float64) {
+	m.metrics.probeTLSCertExpiryDays.WithLabelValues(instance, target, serverName).Set(days)
+}
+
+// SetProbeTLSChainExpiryDays ...
+func (m *Monitor) SetProbeTLSChainExpiryDays(instance, target, serverName string, days float64) {
+	m.metrics.probeTLSChainExpiryDays.WithLabelValues(instance, target, serverName).Set(days)
+}
+
+// SetProbeTLSChainValid ...
+func (m *Monitor) SetProbeTLSChainValid(instance, target, serverName string, valid bool) {
+	m.metrics.probeTLSChainValid.WithLabelValues(instance, target, serverName).Set(boolToFloat(valid))
+}
+
+// SetProbeTLSSNIMismatch ...
+func (m *Monitor) SetProbeTLSSNIMismatch(instance, target, serverName string, mismatch bool) {
+	m.metrics.probeTLSSNIMismatch.WithLabelValues(instance, target, serverName).Set(boolToFloat(mismatch))
+}
+
+// SetProbeTLSInfo ...
+func (m *Monitor) SetProbeTLSInfo(instance, target, serverName, version, cipher string) {
+	m.metrics.probeTLSInfo.DeletePartialMatch(prometheus.Labels{
+		"octopinger_node":        instance,
+		"octopinger_target":      target,
+		"octopinger_server_name": serverName,
+	})
+	m.metrics.probeTLSInfo.WithLabelValues(instance, target, serverName, version, cipher).Set(1)
+}
+
+// IncProbeTLSError ...
+func (m *Monitor) IncProbeTLSError(instance, target, serverName, class string) {
+	m.metrics.probeTLSError.WithLabelValues(instance, target, serverName, class).Inc()
+}
+
+// ResetProbeService removes the gauges of all service paths in this instance,
+// so that failed paths do not keep the time of their last request.
+func (m *Monitor) ResetProbeService(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeServiceTime.DeletePartialMatch(labels)
+}
+
+// SetProbeServiceTime ...
+func (m *Monitor) SetProbeServiceTime(instance, path string, duration float64) {
+	m.metrics.probeServiceTime.WithLabelValues(instance, path).Set(duration)
+}
+
+// IncProbeServiceSuccess ...
+func (m *Monitor) IncProbeServiceSuccess(instance, path, backendNode string) {
+	m.metrics.probeServiceSuccess.WithLabelValues(instance, path, backendNode).Inc()
+}
+
+// IncProbeServiceError ...
+func (m *Monitor) IncProbeServiceError(instance, path, class string) {
+	m.metrics.probeServiceError.WithLabelValues(instance, path, class).Inc()
+}
+
+// ResetProbeMTU removes the series of all targets of the MTU probe in this instance.
+func (m *Monitor) ResetProbeMTU(instance string) {
+	labels := prometheus.Labels{"octopinger_node": instance}
+
+	m.metrics.probeMTUSizeSuccess.DeletePartialMatch(labels)
+	m.metrics.probeMTUPath.DeletePartialMatch(labels)
+	m.metrics.probeMTUBelowExpected.DeletePartialMatch(labels)
+}
+
+// SetProbeMTUSizeSuccess ...
+func (m *Monitor) SetProbeMTUSizeSuccess(instance, target, targetNode, family string, size int, success bool) {
+	m.metrics.probeMTUSizeSuccess.WithLabelValues(instance, target, targetNode, family, strconv.Itoa(size)).Set(boolToFloat(success))
+}
+
+// SetProbeMTUPath ...
+func (m *Monitor) SetProbeMTUPath(instance, target, targetNode, family string, mtu int) {
+	m.metrics.probeMTUPath.WithLabelValues(instance, target, targetNode, family).Set(float64(mtu))
+}
+
+// SetProbeMTUBelowExpected ...
+func (m *Monitor) SetProbeMTUBelowExpected(instance, target, targetNode, family string, below bool) {
+	m.metrics.probeMTUBelowExpected.WithLabelValues(instance, target, targetNode, family).Set(boolToFloat(below))
+}
+
+// ConfigureHistograms ...
+func (m *Monitor) ConfigureHistograms(buckets []float64, native bool) {
+	m.metrics.ConfigureHistograms(buckets, native)
+}
+
+// ObserveProbeICMPRtt ...
+func (m *Monitor) ObserveProbeICMPRtt(instance, network, family, targetZone string, rtt float64) {
+	m.observe(func(h *histograms) {
+		h.probeICMPRtt.WithLabelValues(instance, network, family, targetZone).Observe(microsecondsToSeconds(rtt))
+	})
+}
+
+// ObserveProbeDNSLookupTime ...
+func (m *Monitor) ObserveProbeDNSLookupTime(instance, family string, duration float64) {
+	m.observe(func(h *histograms) {
+		h.probeDNSLookupTime.WithLabelValues(instance, family).Observe(microsecondsToSeconds(duration))
+	})
+}
+
+// ObserveProbeDNSCheckTime ...
+func (m *Monitor) ObserveProbeDNSCheckTime(instance, name, recordType, server string, duration float64) {
+	m.observe(func(h *histograms) {
+		h.probeDNSCheckTime.WithLabelValues(instance, name, recordType, server).Observe(microsecondsToSeconds(duration))
+	})
+}
+
+// ObserveProbeDNSUpstreamTime ...
+func (m *Monitor) ObserveProbeDNSUpstreamTime(instance, server, transport, family string, duration float64) {
+	m.observe(func(h *histograms) {
+		h.probeDNSUpstreamTime.WithLabelValues(instance, server, transport, family).Observe(microsecondsToSeconds(duration))
+	})
+}
+
+// ObserveProbeDNSSearchTime ...
+func (m *Monitor) ObserveProbeDNSSearchTime(instance, name string, duration float64) {
+	m.observe(func(h *histograms) {
+		h.probeDNSSearchTime.WithLabelValues(instance, name).Observe(microsecondsToSeconds(duration))
+	})
+}
+
+// ObserveProbeTCPConnectTime ...
+func (m *Monitor) ObserveProbeTCPConnectTime(instance, target, family string, connectTime float64) {
+	m.observe(func(h *histograms) {
+		h.probeTCPConnectTime.WithLabelValues(instance, target, family).Observe(microsecondsToSeconds(connectTime))
+	})
+}
+
+// ObserveProbeHTTPTotalTime ...
+func (m *Monitor) ObserveProbeHTTPTotalTime(instance, target string, duration float64) {
+	m.observe(func(h *histograms) {
+		h.probeHTTPTotalTime.WithLabelValues(instance, target).Observe(microsecondsToSeconds(duration))
+	})
+}
+
+// ObserveProbeServiceTotalTime ...
+func (m *Monitor) ObserveProbeServiceTotalTime(instance, path string, duration float64) {
+	m.observe(func(h *histograms) {
+		h.probeServiceTotalTime.WithLabelValues(instance, path).Observe(microsecondsToSeconds(duration))
+	})
+}
+
+func (m *Monitor) observe(fn func(h *histograms)) {
+	m.metrics.histogramsMux.RLock()
+	defer m.metrics.histogramsMux.RUnlock()
+
+	fn(m.metrics.histograms)
+}
+
+// microsecondsToSeconds converts the durations of the gauges to the base unit of the histograms.
+func microsecondsToSeconds(us float64) float64 {
+	return us / float64(time.Second/time.Microsecond)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
 }
@@ -1,23 +1,125 @@
 package octopinger
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewMetrics(t *testing.T) {
 	m := NewMetrics()
 
-	assert.NotNil(t, m.probeDNSError)
-	assert.NotNil(t, m.probeDNSSuccess)
-	assert.NotNil(t, m.probeNodesReports)
-	assert.NotNil(t, m.probeNodesTotal)
-	assert.NotNil(t, m.probePacketLossMax)
-	assert.NotNil(t, m.probePacketLossMean)
-	assert.NotNil(t, m.probePacketLossMin)
-	assert.NotNil(t, m.probePacketLossTotal)
-	assert.NotNil(t, m.probeRttMax)
-	assert.NotNil(t, m.probeRttMean)
-	assert.NotNil(t, m.probeRttMin)
+	// the pedantic registry checks that every collected metric is described
+	reg := prometheus.NewPedanticRegistry()
+	assert.NoError(t, reg.Register(m))
+
+	monitor := NewMonitor(m)
+	monitor.SetProbeRttMin("monalisa", "icmp", 100)
+	monitor.SetProbeTargetRttMean("monalisa", "icmp", "10.0.0.2", "node-b", "de-fra-2", "host", "IPv4", 200)
+	monitor.ObserveProbeICMPRtt("monalisa", "host", "IPv4", "de-fra-2", 200)
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+
+	assert.Equal(t, []string{
+		"octopinger_probe_icmp_rtt_seconds",
+		"octopinger_probe_rtt_min",
+		"octopinger_probe_target_rtt_mean",
+	}, names)
+}
+
+func TestProbeLoss(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewMetrics()
+	assert.NoError(t, reg.Register(m))
+
+	monitor := NewMonitor(m)
+	monitor.SetProbePacketLossMin("monalisa", "icmp", 0)
+	monitor.SetProbePacketLossMax("monalisa", "icmp", 0.5)
+	monitor.SetProbePacketLossMean("monalisa", "icmp", 0.25)
+	monitor.SetProbePacketLossTotal("monalisa", "icmp", 0.5)
+
+	expected := `
+# HELP octopinger_probe_loss_max Max fraction of lost packets to the targets, from 0 to 1.
+# TYPE octopinger_probe_loss_max gauge
+octopinger_probe_loss_max{octopinger_node="monalisa",octopinger_probe="icmp"} 0.5
+# HELP octopinger_probe_loss_mean Mean fraction of lost packets to the targets, from 0 to 1.
+# TYPE octopinger_probe_loss_mean gauge
+octopinger_probe_loss_mean{octopinger_node="monalisa",octopinger_probe="icmp"} 0.25
+# HELP octopinger_probe_loss_min Min fraction of lost packets to the targets, from 0 to 1.
+# TYPE octopinger_probe_loss_min gauge
+octopinger_probe_loss_min{octopinger_node="monalisa",octopinger_probe="icmp"} 0
+# HELP octopinger_probe_loss_total Sum of the fractions of lost packets to the targets.
+# TYPE octopinger_probe_loss_total gauge
+octopinger_probe_loss_total{octopinger_node="monalisa",octopinger_probe="icmp"} 0.5
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"octopinger_probe_loss_min", "octopinger_probe_loss_max", "octopinger_probe_loss_mean", "octopinger_probe_loss_total"))
+}
+
+func TestProbeTarget(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewMetrics()
+	assert.NoError(t, reg.Register(m))
+
+	monitor := NewMonitor(m)
+	monitor.SetProbeTargetRttMin("monalisa", "icmp", "10.0.0.2", "node-b", "de-fra-2", "host", "IPv4", 100)
+	monitor.SetProbeTargetRttMean("monalisa", "icmp", "10.0.0.2", "node-b", "de-fra-2", "host", "IPv4", 200)
+	monitor.SetProbeTargetRttMax("monalisa", "icmp", "10.0.0.2", "node-b", "de-fra-2", "host", "IPv4", 300)
+	monitor.SetProbeTargetLoss("monalisa", "icmp", "10.0.0.2", "node-b", "de-fra-2", "host", "IPv4", 0.25)
+	monitor.SetProbeTargetLoss("monalisa", "icmp", "fd00::2", "node-b", "de-fra-2", "host", "IPv6", 1)
+
+	expected := `
+# HELP octopinger_probe_target_loss Fraction of lost packets to a target, from 0 to 1.
+# TYPE octopinger_probe_target_loss gauge
+octopinger_probe_target_loss{octopinger_ip_family="IPv4",octopinger_node="monalisa",octopinger_probe="icmp",octopinger_target="10.0.0.2",octopinger_target_network="host",octopinger_target_node="node-b",octopinger_target_zone="de-fra-2"} 0.25
+octopinger_probe_target_loss{octopinger_ip_family="IPv6",octopinger_node="monalisa",octopinger_probe="icmp",octopinger_target="fd00::2",octopinger_target_network="host",octopinger_target_node="node-b",octopinger_target_zone="de-fra-2"} 1
+# HELP octopinger_probe_target_rtt_max Max round-trip time of the probe to a target.
+# TYPE octopinger_probe_target_rtt_max gauge
+octopinger_probe_target_rtt_max{octopinger_ip_family="IPv4",octopinger_node="monalisa",octopinger_probe="icmp",octopinger_target="10.0.0.2",octopinger_target_network="host",octopinger_target_node="node-b",octopinger_target_zone="de-fra-2"} 300
+# HELP octopinger_probe_target_rtt_mean Mean round-trip time of the probe to a target.
+# TYPE octopinger_probe_target_rtt_mean gauge
+octopinger_probe_target_rtt_mean{octopinger_ip_family="IPv4",octopinger_node="monalisa",octopinger_probe="icmp",octopinger_target="10.0.0.2",octopinger_target_network="host",octopinger_target_node="node-b",octopinger_target_zone="de-fra-2"} 200
+# HELP octopinger_probe_target_rtt_min Min round-trip time of the probe to a target.
+# TYPE octopinger_probe_target_rtt_min gauge
+octopinger_probe_target_rtt_min{octopinger_ip_family="IPv4",octopinger_node="monalisa",octopinger_probe="icmp",octopinger_target="10.0.0.2",octopinger_target_network="host",octopinger_target_node="node-b",octopinger_target_zone="de-fra-2"} 100
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"octopinger_probe_target_rtt_min", "octopinger_probe_target_rtt_mean", "octopinger_probe_target_rtt_max", "octopinger_probe_target_loss"))
+}
+
+func TestConfigureHistograms(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	monitor.ObserveProbeICMPRtt("monalisa", "host", "IPv4", "de-fra-1", 1500)
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_icmp_rtt_seconds"))
+
+	// the distributions are kept as long as the buckets do not change
+	monitor.ConfigureHistograms(nil, false)
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_icmp_rtt_seconds"))
+
+	monitor.ConfigureHistograms([]float64{0.001, 0.002}, true)
+	assert.Equal(t, 0, testutil.CollectAndCount(m, "octopinger_probe_icmp_rtt_seconds"))
+
+	monitor.ObserveProbeICMPRtt("monalisa", "host", "IPv4", "de-fra-1", 1500)
+
+	expected := `
+# HELP octopinger_probe_icmp_rtt_seconds Distribution of the round-trip time of the echo replies of the ICMP probe.
+# TYPE octopinger_probe_icmp_rtt_seconds histogram
+octopinger_probe_icmp_rtt_seconds_bucket{octopinger_ip_family="IPv4",octopinger_network="host",octopinger_node="monalisa",octopinger_target_zone="de-fra-1",le="0.001"} 0
+octopinger_probe_icmp_rtt_seconds_bucket{octopinger_ip_family="IPv4",octopinger_network="host",octopinger_node="monalisa",octopinger_target_zone="de-fra-1",le="0.002"} 1
+octopinger_probe_icmp_rtt_seconds_bucket{octopinger_ip_family="IPv4",octopinger_network="host",octopinger_node="monalisa",octopinger_target_zone="de-fra-1",le="+Inf"} 1
+octopinger_probe_icmp_rtt_seconds_sum{octopinger_ip_family="IPv4",octopinger_network="host",octopinger_node="monalisa",octopinger_target_zone="de-fra-1"} 0.0015
+octopinger_probe_icmp_rtt_seconds_count{octopinger_ip_family="IPv4",octopinger_network="host",octopinger_node="monalisa",octopinger_target_zone="de-fra-1"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(m, strings.NewReader(expected), "octopinger_probe_icmp_rtt_seconds"))
 }
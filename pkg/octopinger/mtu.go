@@ -0,0 +1,377 @@
+package octopinger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+const (
+	defaultMTU        = 1500
+	defaultMTUTimeout = time.Second
+
+	minIPv4MTU = 68
+	minIPv6MTU = 1280
+	maxMTU     = 65535
+)
+
+// ErrNoEchoReply ...
+var ErrNoEchoReply = errors.New("no echo reply")
+
+// minMTU returns the minimum MTU of the family, which every path has to support.
+func minMTU(family v1alpha1.IPFamily) int {
+	if family == v1alpha1.IPFamilyIPv6 {
+		return minIPv6MTU
+	}
+
+	return minIPv4MTU
+}
+
+type mtuStat struct {
+	target     string
+	targetNode string
+	targetZone string
+	network    string
+	ipFamily   string
+	sizes      map[int]error
+	pathMTU    int
+	err        error
+}
+
+type mtuStats struct {
+	values []mtuStat
+
+	expected  int
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (m *mtuStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeMTU(m.nodeName)
+
+	results := make([]Result, 0, len(m.values))
+
+	for _, v := range m.values {
+		result := Result{
+			Target:     v.target,
+			TargetNode: v.targetNode,
+			TargetZone: v.targetZone,
+			Network:    v.network,
+			IPFamily:   v.ipFamily,
+			MTU:        v.pathMTU,
+		}
+
+		if v.err != nil {
+			result.Loss = 1
+			result.Error = v.err.Error()
+			results = append(results, result)
+
+			continue
+		}
+
+		sizes := make([]int, 0, len(v.sizes))
+		for size := range v.sizes {
+			sizes = append(sizes, size)
+		}
+		sort.Ints(sizes)
+
+		failed := 0
+		for _, size := range sizes {
+			monitor.SetProbeMTUSizeSuccess(m.nodeName, v.target, v.targetNode, v.ipFamily, size, v.sizes[size] == nil)
+
+			if v.sizes[size] != nil {
+				failed++
+			}
+		}
+
+		if len(sizes) > 0 {
+			result.Loss = float64(failed) / float64(len(sizes))
+		}
+
+		below := v.pathMTU < m.expected
+		if below {
+			result.Error = fmt.Sprintf("path mtu %d is below the expected mtu %d", v.pathMTU, m.expected)
+		}
+
+		monitor.SetProbeMTUPath(m.nodeName, v.target, v.targetNode, v.ipFamily, v.pathMTU)
+		monitor.SetProbeMTUBelowExpected(m.nodeName, v.target, v.targetNode, v.ipFamily, below)
+
+		results = append(results, result)
+	}
+
+	monitor.SetProbeResults(m.nodeName, m.probeName, results)
+
+	return nil
+}
+
+// Collect ...
+func (m *mtuStats) Collect(ch chan<- Metric) {
+	ch <- m
+}
+
+// NewMTUStats ...
+func NewMTUStats(probeName, nodeName string, expected int) *mtuStats {
+	return &mtuStats{
+		expected:  expected,
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+type mtuProbe struct {
+	opts *Opts
+
+	name     string
+	nodeName string
+
+	mtuStats *mtuStats
+
+	echo     EchoFunc
+	socket   v1alpha1.ICMPSocket
+	timeout  time.Duration
+	sizes    []int
+	search   bool
+	expected int
+
+	maxConcurrency int
+
+	sem chan token
+	wg  sync.WaitGroup
+	mux sync.Mutex
+}
+
+// NewMTUProbe ...
+func NewMTUProbe(nodeName string, opts ...Opt) *mtuProbe {
+	options := new(Opts)
+	options.Configure(opts...)
+
+	m := new(mtuProbe)
+	m.opts = options
+	m.name = "mtu"
+	m.nodeName = nodeName
+	m.timeout = defaultMTUTimeout
+	m.expected = defaultMTU
+	m.maxConcurrency = 100
+	m.sem = make(chan token, m.maxConcurrency)
+
+	m.Reset()
+
+	return m
+}
+
+func (m *mtuProbe) configure(c *v1alpha1.Config) error {
+	if c.MTU.Timeout != "" {
+		s, err := time.ParseDuration(c.MTU.Timeout)
+		if err != nil {
+			return err
+		}
+
+		m.timeout = s
+	}
+
+	if c.MTU.Expected > 0 {
+		m.expected = c.MTU.Expected
+	}
+
+	m.sizes = c.MTU.Sizes
+	m.search = c.MTU.Search
+	m.socket = c.ICMP.Socket
+
+	m.Reset()
+
+	return nil
+}
+
+// Name ...
+func (m *mtuProbe) Name() string {
+	return m.name
+}
+
+// Reset ...
+func (m *mtuProbe) Reset() {
+	m.mtuStats = NewMTUStats(m.name, m.nodeName, m.expected)
+}
+
+// Collect ...
+func (m *mtuProbe) Collect(ch chan<- Metric) {
+	m.mtuStats.Collect(ch)
+}
+
+// AddStat ...
+func (m *mtuProbe) AddStat(stat mtuStat) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.mtuStats.values = append(m.mtuStats.values, stat)
+}
+
+// Do ...
+func (m *mtuProbe) Do(ctx context.Context, metrics Gatherer) func() error {
+	return func() error {
+		err := m.configure(m.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(m.opts.interval)
+		defer ticker.Stop()
+
+		loaders := []NodeLoader{
+			NodesLoader(m.opts.configPath),
+		}
+
+		filters := []NodeFilter{
+			FilterNode(m.nodeName),
+			FilterIP(m.opts.ownIPs()...),
+			FilterNetwork(m.opts.config.Network),
+			FilterIPFamily(m.opts.ipFamilies...),
+		}
+
+		nodeList := NewNodeList(loaders, filters...)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				nodes, err := nodeList.Load()
+				if err != nil {
+					return err
+				}
+
+				m.do(ctx, nodes...)
+
+				metrics.Gather(m)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+func (m *mtuProbe) do(ctx context.Context, targets ...Target) {
+	m.Reset()
+
+	// the targets of a round share one socket per family
+	echo := m.echo
+	if echo == nil {
+		session, err := newEchoSession(m.socket, m.timeout)
+		if err != nil {
+			for _, target := range targets {
+				m.AddStat(mtuStat{target: target.IP, targetNode: target.Node, err: err})
+			}
+
+			return
+		}
+		defer func() { _ = session.Close() }()
+
+		echo = session.Echo
+	}
+
+	for _, target := range targets {
+		target := target
+
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+
+			m.sem <- token{}
+			m.AddStat(m.probe(ctx, echo, target))
+			<-m.sem
+		}()
+	}
+
+	m.wg.Wait()
+}
+
+// probe is sending the configured sizes and is searching the path MTU to the target.
+// Without the search, the path MTU is the largest size that got a reply, up to the expected MTU.
+func (m *mtuProbe) probe(ctx context.Context, echo EchoFunc, target Target) mtuStat {
+	family := target.Family
+	if family == "" {
+		family = IPFamilyOf(target.IP)
+	}
+
+	stat := mtuStat{
+		target:     target.IP,
+		targetNode: target.Node,
+		targetZone: target.Zone,
+		network:    string(target.Network),
+		ipFamily:   string(family),
+		sizes:      make(map[int]error, len(m.sizes)),
+	}
+
+	// small packets have to pass, otherwise the target is not reachable at all
+	lower := minMTU(family)
+	expected := max(m.expected, lower)
+
+	if err := echo(ctx, target.IP, lower); err != nil {
+		stat.err = fmt.Errorf("%w: %v", ErrNoEchoReply, err)
+		return stat
+	}
+	stat.pathMTU = lower
+
+	for _, size := range m.sizes {
+		err := echo(ctx, target.IP, size)
+		stat.sizes[size] = err
+
+		if err == nil && size > stat.pathMTU && size <= expected {
+			stat.pathMTU = size
+		}
+	}
+
+	if m.search {
+		stat.pathMTU = searchMTU(func(size int) bool {
+			return echo(ctx, target.IP, size) == nil
+		}, lower, expected)
+
+		return stat
+	}
+
+	if stat.pathMTU < expected {
+		err, ok := stat.sizes[expected]
+		if !ok {
+			err = echo(ctx, target.IP, expected)
+		}
+
+		if err == nil {
+			stat.pathMTU = expected
+		}
+	}
+
+	return stat
+}
+
+// searchMTU returns the largest size between lower and upper for which the echo succeeds.
+// The echo of lower is expected to succeed.
+func searchMTU(echo func(size int) bool, lower, upper int) int {
+	if echo(upper) {
+		return upper
+	}
+
+	best := lower
+	lo, hi := lower+1, upper-1
+
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+
+		if echo(mid) {
+			best = mid
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,108 @@
+package octopinger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+// pathEcho is simulating a path that drops packets larger than the MTU.
+func pathEcho(mtu int) EchoFunc {
+	return func(_ context.Context, _ string, size int) error {
+		if size > mtu {
+			return errors.New("message too long")
+		}
+
+		return nil
+	}
+}
+
+func TestSearchMTU(t *testing.T) {
+	for _, mtu := range []int{68, 1280, 1450, 1499, 1500} {
+		echo := pathEcho(mtu)
+
+		assert.Equal(t, mtu, searchMTU(func(size int) bool {
+			return echo(context.Background(), "", size) == nil
+		}, minIPv4MTU, defaultMTU))
+	}
+}
+
+func TestMTUProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		search  bool
+		sizes   []int
+		mtu     int
+		pathMTU int
+		failed  []int
+	}{
+		{name: "expected", sizes: []int{1280, 1500}, mtu: 1500, pathMTU: 1500},
+		{name: "sizes", sizes: []int{1280, 1400, 1500}, mtu: 1450, pathMTU: 1400, failed: []int{1500}},
+		{name: "search", search: true, sizes: []int{1500}, mtu: 1450, pathMTU: 1450, failed: []int{1500}},
+		{name: "jumbo", search: true, mtu: 9000, pathMTU: 1500},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewMTUProbe("monalisa")
+			p.echo = pathEcho(tc.mtu)
+			p.sizes = tc.sizes
+			p.search = tc.search
+
+			stat := p.probe(context.Background(), p.echo, Target{IP: "10.0.0.1", Node: "mona", Network: v1alpha1.NetworkPod})
+
+			assert.NoError(t, stat.err)
+			assert.Equal(t, "IPv4", stat.ipFamily)
+			assert.Equal(t, tc.pathMTU, stat.pathMTU)
+			assert.Len(t, stat.sizes, len(tc.sizes))
+
+			for _, size := range tc.failed {
+				assert.Error(t, stat.sizes[size])
+			}
+		})
+	}
+}
+
+func TestMTUProbeUnreachable(t *testing.T) {
+	p := NewMTUProbe("monalisa")
+	p.echo = pathEcho(0)
+
+	stat := p.probe(context.Background(), p.echo, Target{IP: "fd00::1"})
+
+	assert.ErrorIs(t, stat.err, ErrNoEchoReply)
+	assert.Equal(t, "IPv6", stat.ipFamily)
+	assert.Equal(t, 0, stat.pathMTU)
+}
+
+func TestEchoSession(t *testing.T) {
+	conn, err := listenPing(v1alpha1.IPFamilyIPv4, v1alpha1.ICMPSocketRaw, "")
+	if err != nil {
+		t.Skipf("no raw icmp socket: %v", err)
+	}
+	_ = conn.Close()
+
+	session, err := newEchoSession(v1alpha1.ICMPSocketRaw, time.Second)
+	assert.NoError(t, err)
+	defer func() { _ = session.Close() }()
+
+	// the echoes share the socket of the family
+	var wg sync.WaitGroup
+	for _, size := range []int{68, 576, 1280, 1500, 9000} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, session.Echo(context.Background(), "127.0.0.1", size))
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, session.conns, 1)
+	assert.Empty(t, session.pending)
+
+	assert.Error(t, session.Echo(context.Background(), "127.0.0.1", 10))
+}
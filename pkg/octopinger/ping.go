@@ -0,0 +1,609 @@
+package octopinger
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	defaultPingInterval = 100 * time.Millisecond
+	defaultPingSize     = 56
+
+	// pingTokenLen is the length of the random token at the start of the payload,
+	// which matches the replies to the echo requests of a round.
+	pingTokenLen = 8
+)
+
+// PingOpts are the options of the echo requests of Ping.
+type PingOpts struct {
+	// Count is the number of echo requests to each host.
+	Count int
+	// Interval is the time between two echo requests to a host. The default is 100ms.
+	Interval time.Duration
+	// Timeout is the time to wait for the reply of an echo request.
+	Timeout time.Duration
+	// Size is the size of the payload in bytes. The default is 56 bytes.
+	Size int
+	// TTL is the time to live (hop limit) of the echo requests. By default the system default is used.
+	TTL int
+	// TOS is the type of service (traffic class) of the echo requests.
+	TOS int
+	// Source is the source IP of the echo requests of its family.
+	Source string
+	// Socket is the kind of the sockets. The default is "auto".
+	Socket v1alpha1.ICMPSocket
+}
+
+// PingPacket is an echo request to a host.
+type PingPacket struct {
+	// Seq is the sequence number of the request.
+	Seq int
+	// Sent is the time the request was sent, or zero if it was not sent.
+	Sent time.Time
+	// Received is the time of the first reply, or zero if the request was lost.
+	Received time.Time
+	// Duplicates is the number of additional replies to the request.
+	Duplicates int
+	// Reordered is set if the reply arrived after the reply to a later request.
+	Reordered bool
+}
+
+// RTT returns the round-trip time of the packet, or 0 if it was lost.
+func (p PingPacket) RTT() time.Duration {
+	if p.Sent.IsZero() || p.Received.IsZero() {
+		return 0
+	}
+
+	return p.Received.Sub(p.Sent)
+}
+
+// PingStat contains the result of the echo requests to a host.
+type PingStat struct {
+	Host       string
+	IP         string
+	Packets    []PingPacket
+	Sent       int
+	Received   int
+	Duplicates int
+	Reordered  int
+	Loss       float64
+	Best       time.Duration
+	Mean       time.Duration
+	Worst      time.Duration
+	Err        error
+}
+
+// pingTracker is tracking the echo requests to a host by their sequence number.
+type pingTracker struct {
+	host    string
+	ip      net.IP
+	timeout time.Duration
+	packets []PingPacket
+	highest int
+	err     error
+
+	sync.Mutex
+}
+
+func newPingTracker(host string, count int, timeout time.Duration) *pingTracker {
+	t := &pingTracker{
+		host:    host,
+		timeout: timeout,
+		packets: make([]PingPacket, count),
+		highest: -1,
+	}
+
+	for seq := range t.packets {
+		t.packets[seq].Seq = seq
+	}
+
+	return t
+}
+
+// sent records the time an echo request is sent.
+func (t *pingTracker) sent(seq int, at time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	if seq < 0 || seq >= len(t.packets) {
+		return
+	}
+
+	t.packets[seq].Sent = at
+}
+
+// failed records the error of an echo request that could not be sent, which is lost.
+func (t *pingTracker) failed(err error) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.err = err
+}
+
+// received records a reply and returns true for the first reply to a request.
+// Replies after the timeout are lost, replies to requests with a reply are duplicates
+// and replies to requests before the latest request with a reply are reordered.
+func (t *pingTracker) received(seq int, at time.Time) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if seq < 0 || seq >= len(t.packets) {
+		return false
+	}
+
+	p := &t.packets[seq]
+
+	if p.Sent.IsZero() || at.Sub(p.Sent) > t.timeout {
+		return false
+	}
+
+	if !p.Received.IsZero() {
+		p.Duplicates++
+		return false
+	}
+
+	p.Received = at
+
+	if seq < t.highest {
+		p.Reordered = true
+	} else {
+		t.highest = seq
+	}
+
+	return true
+}
+
+// stat returns the stats of the echo requests.
+func (t *pingTracker) stat() PingStat {
+	t.Lock()
+	defer t.Unlock()
+
+	stat := PingStat{
+		Host:    t.host,
+		Packets: append([]PingPacket(nil), t.packets...),
+		Err:     t.err,
+	}
+
+	if t.ip != nil {
+		stat.IP = t.ip.String()
+	}
+
+	var total time.Duration
+
+	for _, p := range t.packets {
+		if p.Sent.IsZero() {
+			continue
+		}
+		stat.Sent++
+		stat.Duplicates += p.Duplicates
+
+		if p.Received.IsZero() {
+			continue
+		}
+		stat.Received++
+
+		if p.Reordered {
+			stat.Reordered++
+		}
+
+		rtt := p.RTT()
+		total += rtt
+
+		if stat.Best == 0 || rtt < stat.Best {
+			stat.Best = rtt
+		}
+
+		if rtt > stat.Worst {
+			stat.Worst = rtt
+		}
+	}
+
+	stat.Loss = 1
+	if stat.Sent > 0 {
+		stat.Loss = 1 - float64(stat.Received)/float64(stat.Sent)
+	}
+
+	if stat.Received > 0 {
+		stat.Mean = total / time.Duration(stat.Received)
+	}
+
+	if stat.Err == nil && stat.Sent > 0 && stat.Received == 0 {
+		stat.Err = ErrPacketLoss
+	}
+
+	return stat
+}
+
+// Ping is sending echo requests to the hosts and returns the stats of each host.
+// The hosts share one socket per family, and the replies are matched to the
+// requests by their sequence number and a random token in the payload.
+func Ping(ctx context.Context, opts PingOpts, hosts ...string) ([]PingStat, error) {
+	if opts.Interval == 0 {
+		opts.Interval = defaultPingInterval
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	if opts.Size == 0 {
+		opts.Size = defaultPingSize
+	}
+
+	if opts.Size < pingTokenLen {
+		return nil, fmt.Errorf("invalid size: %d", opts.Size)
+	}
+
+	data := make([]byte, opts.Size)
+	if _, err := crand.Read(data[:pingTokenLen]); err != nil {
+		return nil, err
+	}
+	token := data[:pingTokenLen]
+	id := rand.Intn(0xffff)
+
+	conns := make(map[v1alpha1.IPFamily]*pingConn)
+	connErrs := make(map[v1alpha1.IPFamily]error)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	trackers := make([]*pingTracker, 0, len(hosts))
+	byIP := make(map[string][]*pingTracker)
+
+	for _, host := range hosts {
+		t := newPingTracker(host, opts.Count, opts.Timeout)
+		trackers = append(trackers, t)
+
+		ip, err := resolvePingHost(ctx, host)
+		if err != nil {
+			t.err = err
+			continue
+		}
+		t.ip = ip
+
+		family := IPFamilyOf(ip.String())
+		if _, ok := conns[family]; !ok && connErrs[family] == nil {
+			conn, err := listenPingConn(family, opts)
+			if err != nil {
+				connErrs[family] = err
+			} else {
+				conns[family] = conn
+			}
+		}
+
+		// the hosts of a family without a socket are lost, e.g. if IPv6 is disabled on the node
+		if err := connErrs[family]; err != nil {
+			t.err = err
+			continue
+		}
+
+		byIP[ip.String()] = append(byIP[ip.String()], t)
+	}
+
+	var outstanding atomic.Int64
+	done := make(chan struct{})
+	var once sync.Once
+
+	reply := func() {
+		if outstanding.Add(-1) == 0 {
+			once.Do(func() { close(done) })
+		}
+	}
+
+	senders := make([]*pingTracker, 0, len(trackers))
+	for _, t := range trackers {
+		if t.ip != nil && t.err == nil {
+			senders = append(senders, t)
+		}
+	}
+	outstanding.Store(int64(len(senders) * opts.Count))
+
+	if len(senders) == 0 || opts.Count == 0 {
+		once.Do(func() { close(done) })
+	}
+
+	var readers sync.WaitGroup
+	for _, conn := range conns {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+
+			buf := make([]byte, opts.Size+ipv6.HeaderLen+icmpHeaderLen)
+			for {
+				n, peer, err := conn.ReadFrom(buf)
+				at := time.Now()
+				if err != nil {
+					return
+				}
+
+				ip, echo, ok := conn.reply(buf[:n], peer, id, token)
+				if !ok {
+					continue
+				}
+
+				for _, t := range byIP[ip.String()] {
+					if t.received(echo.Seq, at) {
+						reply()
+					}
+				}
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for _, t := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn := conns[IPFamilyOf(t.ip.String())]
+
+			ticker := time.NewTicker(opts.Interval)
+			defer ticker.Stop()
+
+			for seq := 0; seq < opts.Count; seq++ {
+				if seq > 0 {
+					select {
+					case <-ctx.Done():
+						for ; seq < opts.Count; seq++ {
+							reply()
+						}
+						return
+					case <-ticker.C:
+					}
+				}
+
+				t.sent(seq, time.Now())
+
+				if err := conn.write(t.ip, id, seq, data); err != nil {
+					t.failed(err)
+					reply()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	timer := time.NewTimer(opts.Timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	readers.Wait()
+
+	stats := make([]PingStat, 0, len(trackers))
+	for _, t := range trackers {
+		stats = append(stats, t.stat())
+	}
+
+	return stats, nil
+}
+
+// listenPingConn opens and configures the socket of a family for the options.
+func listenPingConn(family v1alpha1.IPFamily, opts PingOpts) (*pingConn, error) {
+	source := ""
+	if IPFamilyOf(opts.Source) == family {
+		source = opts.Source
+	}
+
+	conn, err := listenPing(family, opts.Socket, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.configure(opts.TTL, opts.TOS); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// resolvePingHost returns the IP of a host, which is either an IP or a name.
+func resolvePingHost(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no address for %s", host)
+	}
+
+	return ips[0], nil
+}
+
+// pingConn is an ICMP socket of a family, either an unprivileged datagram ping socket or a raw socket.
+type pingConn struct {
+	*icmp.PacketConn
+
+	family   v1alpha1.IPFamily
+	datagram bool
+}
+
+// listenPing opens an ICMP socket of the family. With "auto" a datagram ping socket is opened
+// if the group of the process is in net.ipv4.ping_group_range, and a raw socket otherwise.
+func listenPing(family v1alpha1.IPFamily, socket v1alpha1.ICMPSocket, source string) (*pingConn, error) {
+	datagram, raw, address := "udp4", "ip4:icmp", "0.0.0.0"
+	if family == v1alpha1.IPFamilyIPv6 {
+		datagram, raw, address = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	if source != "" {
+		address = source
+	}
+
+	if socket != v1alpha1.ICMPSocketRaw {
+		conn, err := icmp.ListenPacket(datagram, address)
+		if err == nil {
+			return &pingConn{PacketConn: conn, family: family, datagram: true}, nil
+		}
+
+		if socket == v1alpha1.ICMPSocketDatagram {
+			return nil, fmt.Errorf("datagram ping socket: %w", err)
+		}
+	}
+
+	conn, err := icmp.ListenPacket(raw, address)
+	if err != nil {
+		return nil, fmt.Errorf("raw icmp socket: %w", err)
+	}
+
+	return &pingConn{PacketConn: conn, family: family}, nil
+}
+
+// configure sets the time to live and the type of service of the socket, if they are not 0.
+func (c *pingConn) configure(ttl, tos int) error {
+	if c.family == v1alpha1.IPFamilyIPv6 {
+		p := c.IPv6PacketConn()
+
+		if ttl > 0 {
+			if err := p.SetHopLimit(ttl); err != nil {
+				return err
+			}
+		}
+
+		if tos > 0 {
+			return p.SetTrafficClass(tos)
+		}
+
+		return nil
+	}
+
+	p := c.IPv4PacketConn()
+
+	if ttl > 0 {
+		if err := p.SetTTL(ttl); err != nil {
+			return err
+		}
+	}
+
+	if tos > 0 {
+		return p.SetTOS(tos)
+	}
+
+	return nil
+}
+
+// control calls f with the file descriptor of the socket.
+func (c *pingConn) control(f func(fd uintptr) error) error {
+	var conn net.PacketConn
+	if c.family == v1alpha1.IPFamilyIPv6 {
+		conn = c.IPv6PacketConn().PacketConn
+	} else {
+		conn = c.IPv4PacketConn().PacketConn
+	}
+
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("unsupported socket: %T", conn)
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = f(fd) }); err != nil {
+		return err
+	}
+
+	return ferr
+}
+
+// headerLen returns the length of the IP header of the family.
+func (c *pingConn) headerLen() int {
+	if c.family == v1alpha1.IPFamilyIPv6 {
+		return ipv6.HeaderLen
+	}
+
+	return ipv4.HeaderLen
+}
+
+// write sends an echo request to the IP.
+func (c *pingConn) write(ip net.IP, id, seq int, data []byte) error {
+	var request icmp.Type = ipv4.ICMPTypeEcho
+	if c.family == v1alpha1.IPFamilyIPv6 {
+		request = ipv6.ICMPTypeEchoRequest
+	}
+
+	msg := icmp.Message{
+		Type: request,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: data},
+	}
+
+	bb, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+
+	// datagram ping sockets are addressed like UDP sockets
+	var addr net.Addr = &net.IPAddr{IP: ip}
+	if c.datagram {
+		addr = &net.UDPAddr{IP: ip}
+	}
+
+	_, err = c.WriteTo(bb, addr)
+
+	return err
+}
+
+// reply returns the peer and the echo of an echo reply with the id and the token.
+// Raw sockets receive all ICMP messages of the host, which are matched by the id.
+// The kernel replaces the id of datagram ping sockets and only passes their own replies,
+// so these are matched by the token alone.
+func (c *pingConn) reply(b []byte, peer net.Addr, id int, token []byte) (net.IP, *icmp.Echo, bool) {
+	protocol, reply := protocolICMP, icmp.Type(ipv4.ICMPTypeEchoReply)
+	if c.family == v1alpha1.IPFamilyIPv6 {
+		protocol, reply = protocolICMPv6, ipv6.ICMPTypeEchoReply
+	}
+
+	m, err := icmp.ParseMessage(protocol, b)
+	if err != nil || m.Type != reply {
+		return nil, nil, false
+	}
+
+	e, ok := m.Body.(*icmp.Echo)
+	if !ok || (!c.datagram && e.ID != id) || !bytes.HasPrefix(e.Data, token) {
+		return nil, nil, false
+	}
+
+	var ip net.IP
+	switch addr := peer.(type) {
+	case *net.IPAddr:
+		ip = addr.IP
+	case *net.UDPAddr:
+		ip = addr.IP
+	default:
+		return nil, nil, false
+	}
+
+	return ip, e, true
+}
@@ -0,0 +1,118 @@
+package octopinger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPingTracker(t *testing.T) {
+	start := time.Now()
+
+	tracker := newPingTracker("10.0.0.1", 4, time.Second)
+	for seq := 0; seq < 4; seq++ {
+		tracker.sent(seq, start.Add(time.Duration(seq)*100*time.Millisecond))
+	}
+
+	assert.True(t, tracker.received(0, start.Add(30*time.Millisecond)))
+	assert.True(t, tracker.received(2, start.Add(220*time.Millisecond)))
+	assert.True(t, tracker.received(1, start.Add(230*time.Millisecond)))
+	assert.False(t, tracker.received(1, start.Add(240*time.Millisecond)))
+	assert.False(t, tracker.received(3, start.Add(2*time.Second)))
+	assert.False(t, tracker.received(7, start.Add(250*time.Millisecond)))
+
+	stat := tracker.stat()
+
+	assert.Equal(t, "10.0.0.1", stat.Host)
+	assert.Equal(t, 4, stat.Sent)
+	assert.Equal(t, 3, stat.Received)
+	assert.Equal(t, 1, stat.Duplicates)
+	assert.Equal(t, 1, stat.Reordered)
+	assert.Equal(t, 0.25, stat.Loss)
+	assert.Equal(t, 20*time.Millisecond, stat.Best)
+	assert.Equal(t, 130*time.Millisecond, stat.Worst)
+	assert.Equal(t, 60*time.Millisecond, stat.Mean)
+	assert.NoError(t, stat.Err)
+
+	assert.True(t, stat.Packets[1].Reordered)
+	assert.Equal(t, 1, stat.Packets[1].Duplicates)
+	assert.Equal(t, 130*time.Millisecond, stat.Packets[1].RTT())
+	assert.Zero(t, stat.Packets[3].RTT())
+}
+
+func TestPingTrackerLost(t *testing.T) {
+	tracker := newPingTracker("10.0.0.1", 2, time.Second)
+	tracker.sent(0, time.Now())
+	tracker.sent(1, time.Now())
+
+	stat := tracker.stat()
+
+	assert.Equal(t, float64(1), stat.Loss)
+	assert.ErrorIs(t, stat.Err, ErrPacketLoss)
+}
+
+func TestPing(t *testing.T) {
+	for _, socket := range []v1alpha1.ICMPSocket{v1alpha1.ICMPSocketDatagram, v1alpha1.ICMPSocketRaw} {
+		t.Run(string(socket), func(t *testing.T) {
+			conn, err := listenPing(v1alpha1.IPFamilyIPv4, socket, "")
+			if err != nil {
+				t.Skipf("no %s icmp socket: %v", socket, err)
+			}
+			_ = conn.Close()
+
+			opts := PingOpts{Count: 3, Interval: 10 * time.Millisecond, Timeout: time.Second, TTL: 64, Socket: socket}
+
+			stats, err := Ping(context.Background(), opts, "127.0.0.1", "localhost")
+			assert.NoError(t, err)
+			assert.Len(t, stats, 2)
+
+			for _, stat := range stats {
+				assert.Equal(t, "127.0.0.1", stat.IP)
+				assert.Equal(t, 3, stat.Sent)
+				assert.Equal(t, 3, stat.Received)
+				assert.Zero(t, stat.Loss)
+				assert.Positive(t, stat.Mean)
+				assert.NoError(t, stat.Err)
+			}
+		})
+	}
+}
+
+func TestPingSocketError(t *testing.T) {
+	conn, err := listenPing(v1alpha1.IPFamilyIPv4, v1alpha1.ICMPSocketDatagram, "")
+	if err == nil {
+		_ = conn.Close()
+		t.Skip("datagram icmp sockets are permitted")
+	}
+
+	stats, err := Ping(context.Background(), PingOpts{Count: 1, Socket: v1alpha1.ICMPSocketDatagram}, "127.0.0.1")
+	assert.NoError(t, err)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, float64(1), stats[0].Loss)
+	assert.Zero(t, stats[0].Sent)
+	assert.Error(t, stats[0].Err)
+}
+
+func TestPingDualStack(t *testing.T) {
+	for _, family := range []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4, v1alpha1.IPFamilyIPv6} {
+		conn, err := listenPing(family, v1alpha1.ICMPSocketRaw, "")
+		if err != nil {
+			t.Skipf("no %s icmp socket: %v", family, err)
+		}
+		_ = conn.Close()
+	}
+
+	opts := PingOpts{Count: 2, Interval: 10 * time.Millisecond, Timeout: time.Second, TTL: 64, Socket: v1alpha1.ICMPSocketRaw}
+
+	stats, err := Ping(context.Background(), opts, "127.0.0.1", "::1")
+	assert.NoError(t, err)
+	assert.Len(t, stats, 2)
+
+	for _, stat := range stats {
+		assert.Equal(t, 2, stat.Received, stat.Host)
+		assert.NoError(t, stat.Err)
+	}
+}
@@ -0,0 +1,149 @@
+package octopinger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result is the latest result of a probe for a single target.
+type Result struct {
+	// Target is the probed address or name.
+	Target string `json:"target"`
+	// TargetNode is the name of the probed node, if known.
+	TargetNode string `json:"target_node,omitempty"`
+	// TargetZone is the topology zone of the probed node, if known.
+	TargetZone string `json:"target_zone,omitempty"`
+	// Network is the network of the probed address, if known.
+	Network string `json:"network,omitempty"`
+	// IPFamily is the IP family of the probed address, if known.
+	IPFamily string `json:"ip_family,omitempty"`
+	// RecordType is the type of the queried DNS records, if any.
+	RecordType string `json:"record_type,omitempty"`
+	// Server is the queried DNS server, if any.
+	Server string `json:"server,omitempty"`
+	// Loss is the fraction of failed attempts, from 0 to 1.
+	Loss float64 `json:"loss"`
+	// RttMin is the min round-trip time in microseconds.
+	RttMin float64 `json:"rtt_min"`
+	// RttMean is the mean round-trip time in microseconds.
+	RttMean float64 `json:"rtt_mean"`
+	// RttMax is the max round-trip time in microseconds.
+	RttMax float64 `json:"rtt_max"`
+	// MTU is the largest packet size in bytes that reached the target, if probed.
+	MTU int `json:"mtu,omitempty"`
+	// Queries is the number of DNS queries of the lookup, if counted.
+	Queries int `json:"queries,omitempty"`
+	// LastSuccess is the last time the target was successfully probed.
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	// Error is the error of the latest round, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// key identifies the target of a result within a probe,
+// which can be probed in several networks, families or record types.
+func (r Result) key() string {
+	return strings.Join([]string{r.Target, r.Network, r.IPFamily, r.RecordType, r.Server}, "/")
+}
+
+// ProbeResults are the results of the latest round of a probe.
+type ProbeResults struct {
+	// Probe is the name of the probe.
+	Probe string `json:"probe"`
+	// Updated is the time the round was finished.
+	Updated time.Time `json:"updated"`
+	// Targets are the results for each target.
+	Targets []Result `json:"targets"`
+}
+
+// ResultsReport is the report of the latest results of all probes.
+type ResultsReport struct {
+	// Node is the name of the node reporting the results.
+	Node string `json:"node"`
+	// Probes are the results of all probes.
+	Probes []ProbeResults `json:"probes"`
+}
+
+// Results holds the latest round of results of all probes.
+type Results struct {
+	node        string
+	probes      map[string]ProbeResults
+	lastSuccess map[string]map[string]time.Time
+
+	sync.RWMutex
+}
+
+// NewResults ...
+func NewResults() *Results {
+	r := new(Results)
+	r.probes = make(map[string]ProbeResults)
+	r.lastSuccess = make(map[string]map[string]time.Time)
+
+	return r
+}
+
+// Set is replacing the results of a probe with the latest round.
+func (r *Results) Set(instance, probe string, results []Result) {
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+
+	lastSuccess := make(map[string]time.Time, len(results))
+	targets := make([]Result, 0, len(results))
+
+	for _, result := range results {
+		key := result.key()
+
+		if result.Error == "" && result.Loss < 1 {
+			lastSuccess[key] = now
+		} else if t, ok := r.lastSuccess[probe][key]; ok {
+			lastSuccess[key] = t
+		}
+
+		if t, ok := lastSuccess[key]; ok {
+			t := t
+			result.LastSuccess = &t
+		}
+
+		targets = append(targets, result)
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Target != targets[j].Target {
+			return targets[i].Target < targets[j].Target
+		}
+
+		return targets[i].key() < targets[j].key()
+	})
+
+	r.node = instance
+	r.lastSuccess[probe] = lastSuccess
+	r.probes[probe] = ProbeResults{
+		Probe:   probe,
+		Updated: now,
+		Targets: targets,
+	}
+}
+
+// Report returns the latest results of all probes.
+func (r *Results) Report() ResultsReport {
+	r.RLock()
+	defer r.RUnlock()
+
+	report := ResultsReport{
+		Node:   r.node,
+		Probes: make([]ProbeResults, 0, len(r.probes)),
+	}
+
+	for _, p := range r.probes {
+		report.Probes = append(report.Probes, p)
+	}
+
+	sort.Slice(report.Probes, func(i, j int) bool {
+		return report.Probes[i].Probe < report.Probes[j].Probe
+	})
+
+	return report
+}
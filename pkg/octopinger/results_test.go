@@ -0,0 +1,49 @@
+package octopinger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultsSet(t *testing.T) {
+	r := NewResults()
+
+	r.Set("monalisa", "icmp", []Result{
+		{Target: "10.0.0.2", Loss: 0},
+		{Target: "10.0.0.1", Loss: 1, Error: "all packets lost"},
+	})
+
+	report := r.Report()
+	assert.Equal(t, "monalisa", report.Node)
+	assert.Len(t, report.Probes, 1)
+	assert.Equal(t, "icmp", report.Probes[0].Probe)
+	assert.Equal(t, "10.0.0.1", report.Probes[0].Targets[0].Target)
+	assert.Nil(t, report.Probes[0].Targets[0].LastSuccess)
+	assert.NotNil(t, report.Probes[0].Targets[1].LastSuccess)
+
+	lastSuccess := *report.Probes[0].Targets[1].LastSuccess
+
+	r.Set("monalisa", "icmp", []Result{
+		{Target: "10.0.0.2", Loss: 1, Error: "all packets lost"},
+	})
+
+	report = r.Report()
+	assert.Len(t, report.Probes[0].Targets, 1)
+	assert.Equal(t, lastSuccess, *report.Probes[0].Targets[0].LastSuccess)
+}
+
+func TestResultsSetRecordTypes(t *testing.T) {
+	r := NewResults()
+
+	r.Set("monalisa", "dns_check", []Result{
+		{Target: "www.ionos.com", RecordType: "MX", Loss: 1, Error: "NXDOMAIN"},
+		{Target: "www.ionos.com", RecordType: "A"},
+	})
+
+	targets := r.Report().Probes[0].Targets
+	assert.Equal(t, "A", targets[0].RecordType)
+	assert.NotNil(t, targets[0].LastSuccess)
+	assert.Equal(t, "MX", targets[1].RecordType)
+	assert.Nil(t, targets[1].LastSuccess)
+}
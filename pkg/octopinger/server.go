@@ -2,13 +2,24 @@ package octopinger
 
 import (
 	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	o "github.com/ionos-cloud/octopinger/internal/opts"
 	srv "github.com/ionos-cloud/octopinger/internal/server"
 	"go.uber.org/zap"
 )
 
+const (
+	defaultDNSTimeout = 3 * time.Second
+)
+
 type server struct {
 	opts *Opts
 	srv.Listener
@@ -16,15 +27,21 @@ type server struct {
 
 // Opts ...
 type Opts struct {
-	configPath string
-	interval   time.Duration
-	logger     *zap.Logger
-	monitor    *Monitor
-	nodeName   string
-	podIP      string
-	hostIP     string
-	timeout    time.Duration
-	config     *v1alpha1.Config
+	configPath   string
+	interval     time.Duration
+	logger       *zap.Logger
+	monitor      *Monitor
+	nodeName     string
+	podIP        string
+	podIPs       []string
+	ipFamilies   []v1alpha1.IPFamily
+	hostIP       string
+	hostIPs      []string
+	serviceName  string
+	serviceIP    string
+	reloadSignal syscall.Signal
+	timeout      time.Duration
+	config       *v1alpha1.Config
 }
 
 // Configure ...
@@ -34,6 +51,13 @@ func (o *Opts) Configure(opts ...Opt) {
 	}
 }
 
+// ownIPs returns the IPs of the node and the pod of this instance.
+func (o *Opts) ownIPs() []string {
+	ips := append([]string{o.hostIP, o.podIP}, o.hostIPs...)
+
+	return append(ips, o.podIPs...)
+}
+
 // Opt ...
 type Opt func(*Opts)
 
@@ -93,6 +117,20 @@ func WithPodIP(ip string) Opt {
 	}
 }
 
+// WithPodIPs ...
+func WithPodIPs(ips []string) Opt {
+	return func(o *Opts) {
+		o.podIPs = ips
+	}
+}
+
+// WithIPFamilies ...
+func WithIPFamilies(families []v1alpha1.IPFamily) Opt {
+	return func(o *Opts) {
+		o.ipFamilies = families
+	}
+}
+
 // WithHostIP ...
 func WithHostIP(ip string) Opt {
 	return func(o *Opts) {
@@ -100,11 +138,47 @@ func WithHostIP(ip string) Opt {
 	}
 }
 
+// WithHostIPs ...
+func WithHostIPs(ips []string) Opt {
+	return func(o *Opts) {
+		o.hostIPs = ips
+	}
+}
+
+// WithServiceName ...
+func WithServiceName(name string) Opt {
+	return func(o *Opts) {
+		o.serviceName = name
+	}
+}
+
+// WithServiceIP ...
+func WithServiceIP(ip string) Opt {
+	return func(o *Opts) {
+		o.serviceIP = ip
+	}
+}
+
+// WithReloadSignal ...
+func WithReloadSignal(sig syscall.Signal) Opt {
+	return func(o *Opts) {
+		o.reloadSignal = sig
+	}
+}
+
 // NewServer ...
 func NewServer(opts ...Opt) *server {
 	options := new(Opts)
 	options.Configure(opts...)
 
+	if options.logger == nil {
+		options.logger = zap.NewNop()
+	}
+
+	if options.reloadSignal == 0 {
+		options.reloadSignal = o.DefaultReloadSignal
+	}
+
 	s := new(server)
 	s.opts = options
 
@@ -119,47 +193,336 @@ func (s *server) Start(ctx context.Context, ready srv.ReadyFunc, run srv.RunFunc
 			return err
 		}
 
-		if cfg.ICMP.Enable {
-			icmp := NewICMPProbe(
-				s.opts.nodeName,
-				WithConfigPath(s.opts.configPath),
-				WithNodeName(s.opts.nodeName),
-				WithLogger(s.opts.logger),
-				WithPodIP(s.opts.podIP),
-				WithHostIP(s.opts.hostIP),
-				WithConfig(cfg),
-			)
+		// the config map is mounted as a directory of symlinks,
+		// which are swapped atomically on updates.
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = watcher.Close() }()
 
-			run(icmp.Do(ctx, s.opts.monitor))
+		err = watcher.Add(s.opts.configPath)
+		if err != nil {
+			return err
 		}
 
-		if cfg.DNS.Enable && len(cfg.DNS.Names) > 0 {
-			timeout := 3 * time.Second
-			if cfg.DNS.Timeout != "" {
-				timeout, err = time.ParseDuration(cfg.DNS.Timeout)
-				if err != nil {
-					return err
-				}
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, s.opts.reloadSignal)
+		defer signal.Stop(reload)
+
+		for {
+			probeCtx, cancel := context.WithCancel(ctx)
+			probes := newProbeGroup()
+
+			err := s.startProbes(probeCtx, cfg, probes.run)
+			if err == nil {
+				cfg, err = s.wait(ctx, cfg, watcher, reload, probes.err)
 			}
 
-			dns := NewDNSProbe(
-				s.opts.nodeName,
-				cfg.DNS.Server,
-				cfg.DNS.Names,
-				WithConfigPath(s.opts.configPath),
-				WithNodeName(s.opts.nodeName),
-				WithLogger(s.opts.logger),
-				WithPodIP(s.opts.podIP),
-				WithHostIP(s.opts.hostIP),
-				WithTimeout(timeout),
-				WithConfig(cfg),
-			)
+			cancel()
+			probes.wg.Wait()
+
+			if err != nil || cfg == nil {
+				return err
+			}
+		}
+	}
+}
+
+// wait is blocking until a changed and valid config is loaded,
+// a probe has failed, or the context is canceled.
+func (s *server) wait(ctx context.Context, cfg *v1alpha1.Config, watcher *fsnotify.Watcher, reload <-chan os.Signal, errs <-chan error) (*v1alpha1.Config, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case err := <-errs:
+			return nil, err
+		case err := <-watcher.Errors:
+			s.opts.logger.Warn("watching config", zap.Error(err))
+			continue
+		case <-watcher.Events:
+		case <-reload:
+		}
 
-			run(dns.Do(ctx, s.opts.monitor))
+		next := s.reload(cfg)
+		if next != nil {
+			return next, nil
 		}
+	}
+}
 
-		<-ctx.Done()
+// reload is returning the new config if it has changed and is valid.
+// Otherwise the last good config is kept and nil is returned.
+func (s *server) reload(cfg *v1alpha1.Config) *v1alpha1.Config {
+	next, err := Config().Load(s.opts.configPath)
+	if err != nil {
+		s.opts.logger.Warn("keeping last good config", zap.Error(err))
+		return nil
+	}
 
+	if reflect.DeepEqual(cfg, next) {
 		return nil
 	}
+
+	s.opts.logger.Info("config changed, restarting probes")
+
+	return next
+}
+
+func (s *server) startProbes(ctx context.Context, cfg *v1alpha1.Config, run srv.RunFunc) error {
+	families := IPFamilies(cfg.IPFamilies, append([]string{s.opts.podIP}, s.opts.podIPs...)...)
+
+	buckets, err := parseBuckets(cfg.Histograms.Buckets)
+	if err != nil {
+		return err
+	}
+
+	if s.opts.monitor != nil {
+		s.opts.monitor.ConfigureHistograms(buckets, cfg.Histograms.Native)
+	}
+
+	if cfg.ICMP.Enable {
+		interval, err := parseInterval(cfg.ICMP.Interval)
+		if err != nil {
+			return err
+		}
+
+		icmp := NewICMPProbe(
+			s.opts.nodeName,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithPodIP(s.opts.podIP),
+			WithPodIPs(s.opts.podIPs),
+			WithHostIP(s.opts.hostIP),
+			WithHostIPs(s.opts.hostIPs),
+			WithIPFamilies(families),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(icmp.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.DNS.Enable && len(cfg.DNS.Names) > 0 {
+		interval, err := parseInterval(cfg.DNS.Interval)
+		if err != nil {
+			return err
+		}
+
+		timeout := defaultDNSTimeout
+		if cfg.DNS.Timeout != "" {
+			timeout, err = time.ParseDuration(cfg.DNS.Timeout)
+			if err != nil {
+				return err
+			}
+		}
+
+		dns := NewDNSProbe(
+			s.opts.nodeName,
+			cfg.DNS.Server,
+			cfg.DNS.Names,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithPodIP(s.opts.podIP),
+			WithPodIPs(s.opts.podIPs),
+			WithHostIP(s.opts.hostIP),
+			WithHostIPs(s.opts.hostIPs),
+			WithIPFamilies(families),
+			WithTimeout(timeout),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(dns.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.DNS.Enable && len(cfg.DNS.Checks) > 0 {
+		interval, err := parseInterval(cfg.DNS.Interval)
+		if err != nil {
+			return err
+		}
+
+		timeout := defaultDNSTimeout
+		if cfg.DNS.Timeout != "" {
+			timeout, err = time.ParseDuration(cfg.DNS.Timeout)
+			if err != nil {
+				return err
+			}
+		}
+
+		checks := NewDNSCheckProbe(
+			s.opts.nodeName,
+			cfg.DNS.Server,
+			cfg.DNS.Checks,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithTimeout(timeout),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(checks.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.DNS.Enable && len(cfg.DNS.SearchPath.Names) > 0 {
+		interval, err := parseInterval(cfg.DNS.Interval)
+		if err != nil {
+			return err
+		}
+
+		timeout := defaultDNSTimeout
+		if cfg.DNS.Timeout != "" {
+			timeout, err = time.ParseDuration(cfg.DNS.Timeout)
+			if err != nil {
+				return err
+			}
+		}
+
+		search := NewDNSSearchProbe(
+			s.opts.nodeName,
+			cfg.DNS.SearchPath.Names,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithIPFamilies(families),
+			WithTimeout(timeout),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(search.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.TCP.Enable && (len(cfg.TCP.Targets) > 0 || cfg.TCP.NodePort > 0) {
+		interval, err := parseInterval(cfg.TCP.Interval)
+		if err != nil {
+			return err
+		}
+
+		tcp := NewTCPProbe(
+			s.opts.nodeName,
+			cfg.TCP.Targets,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithPodIP(s.opts.podIP),
+			WithPodIPs(s.opts.podIPs),
+			WithHostIP(s.opts.hostIP),
+			WithHostIPs(s.opts.hostIPs),
+			WithIPFamilies(families),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(tcp.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.MTU.Enable {
+		interval, err := parseInterval(cfg.MTU.Interval)
+		if err != nil {
+			return err
+		}
+
+		mtu := NewMTUProbe(
+			s.opts.nodeName,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithPodIP(s.opts.podIP),
+			WithPodIPs(s.opts.podIPs),
+			WithHostIP(s.opts.hostIP),
+			WithHostIPs(s.opts.hostIPs),
+			WithIPFamilies(families),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(mtu.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.HTTP.Enable && len(cfg.HTTP.Targets) > 0 {
+		interval, err := parseInterval(cfg.HTTP.Interval)
+		if err != nil {
+			return err
+		}
+
+		http := NewHTTPProbe(
+			s.opts.nodeName,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(http.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.TLS.Enable && (len(cfg.TLS.Targets) > 0 || cfg.TLS.APIServer) {
+		interval, err := parseInterval(cfg.TLS.Interval)
+		if err != nil {
+			return err
+		}
+
+		tls := NewTLSProbe(
+			s.opts.nodeName,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(tls.Do(ctx, s.opts.monitor))
+	}
+
+	if cfg.Service.Enable && (s.opts.serviceName != "" || s.opts.serviceIP != "") {
+		interval, err := parseInterval(cfg.Service.Interval)
+		if err != nil {
+			return err
+		}
+
+		service := NewServiceProbe(
+			s.opts.nodeName,
+			WithConfigPath(s.opts.configPath),
+			WithNodeName(s.opts.nodeName),
+			WithLogger(s.opts.logger),
+			WithServiceName(s.opts.serviceName),
+			WithServiceIP(s.opts.serviceIP),
+			WithInterval(interval),
+			WithConfig(cfg),
+		)
+
+		run(service.Do(ctx, s.opts.monitor))
+	}
+
+	return nil
+}
+
+type probeGroup struct {
+	wg  sync.WaitGroup
+	err chan error
+}
+
+func newProbeGroup() *probeGroup {
+	return &probeGroup{err: make(chan error, 1)}
+}
+
+func (g *probeGroup) run(fn func() error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		if err := fn(); err != nil {
+			select {
+			case g.err <- err:
+			default:
+			}
+		}
+	}()
 }
@@ -0,0 +1,52 @@
+package octopinger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerReload(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "config")
+
+	assert.NoError(t, os.WriteFile(p, []byte(`{"icmp":{"enable":true}}`), 0o600))
+
+	s := NewServer(WithConfigPath(dir))
+
+	cfg, err := Config().Load(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, s.reload(cfg))
+
+	assert.NoError(t, os.WriteFile(p, []byte(`{"icmp":{"enable":true,"interval":"soon"}}`), 0o600))
+	assert.Nil(t, s.reload(cfg))
+
+	assert.NoError(t, os.WriteFile(p, []byte(`{"icmp":{"enable":true,"interval":"10s"}}`), 0o600))
+	assert.Equal(t, &v1alpha1.Config{ICMP: v1alpha1.ICMP{Enable: true, Interval: "10s"}}, s.reload(cfg))
+}
+
+func TestServerStartCancel(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config"), []byte(`{}`), 0o600))
+
+	s := NewServer(WithConfigPath(dir))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Start(ctx, nil, nil)() }()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config"), []byte(`{"network":"pod"}`), 0o600))
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "server did not stop")
+	}
+}
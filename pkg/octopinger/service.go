@@ -0,0 +1,265 @@
+package octopinger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+const (
+	// ServicePathClusterIP is connecting to the Service by its ClusterIP.
+	ServicePathClusterIP = "cluster_ip"
+	// ServicePathDNS is connecting to the Service by its DNS name.
+	ServicePathDNS = "dns"
+
+	// StatusPort is the port of the status server of the instances, which serves the Service probe.
+	StatusPort = 8081
+)
+
+// Instance is identifying the Octopinger instance that answered a request.
+type Instance struct {
+	// Node is the name of the node of the instance.
+	Node string `json:"node"`
+}
+
+type serviceStat struct {
+	path        string
+	target      string
+	backendNode string
+	time        time.Duration
+	err         error
+}
+
+type serviceStats struct {
+	values []serviceStat
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (s *serviceStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeService(s.nodeName)
+
+	results := make([]Result, 0, len(s.values))
+
+	for _, v := range s.values {
+		result := Result{
+			Target:     v.target,
+			TargetNode: v.backendNode,
+		}
+
+		if v.err != nil {
+			monitor.IncProbeServiceError(s.nodeName, v.path, HTTPErrorClass(v.err))
+
+			result.Loss = 1
+			result.Error = v.err.Error()
+		} else {
+			monitor.IncProbeServiceSuccess(s.nodeName, v.path, v.backendNode)
+			monitor.SetProbeServiceTime(s.nodeName, v.path, float64(v.time.Microseconds()))
+			monitor.ObserveProbeServiceTotalTime(s.nodeName, v.path, float64(v.time.Microseconds()))
+
+			result.RttMin = float64(v.time.Microseconds())
+			result.RttMean = float64(v.time.Microseconds())
+			result.RttMax = float64(v.time.Microseconds())
+		}
+
+		results = append(results, result)
+	}
+
+	monitor.SetProbeResults(s.nodeName, s.probeName, results)
+
+	return nil
+}
+
+// Collect ...
+func (s *serviceStats) Collect(ch chan<- Metric) {
+	ch <- s
+}
+
+// NewServiceStats ...
+func NewServiceStats(probeName, nodeName string) *serviceStats {
+	return &serviceStats{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+type serviceProbe struct {
+	opts *Opts
+
+	name     string
+	nodeName string
+	targets  map[string]string
+
+	serviceStats *serviceStats
+
+	timeout time.Duration
+	client  *http.Client
+
+	wg  sync.WaitGroup
+	mux sync.Mutex
+}
+
+// NewServiceProbe ...
+func NewServiceProbe(nodeName string, opts ...Opt) *serviceProbe {
+	options := new(Opts)
+	options.Configure(opts...)
+
+	s := new(serviceProbe)
+	s.opts = options
+	s.name = "service"
+	s.nodeName = nodeName
+	s.timeout = defaultTimeout
+	s.targets = make(map[string]string)
+
+	port := strconv.Itoa(StatusPort)
+
+	if options.serviceIP != "" {
+		s.targets[ServicePathClusterIP] = net.JoinHostPort(options.serviceIP, port)
+	}
+
+	if options.serviceName != "" {
+		s.targets[ServicePathDNS] = net.JoinHostPort(options.serviceName, port)
+	}
+
+	s.Reset()
+
+	return s
+}
+
+func (s *serviceProbe) configure(c *v1alpha1.Config) error {
+	if c.Service.Timeout != "" {
+		t, err := time.ParseDuration(c.Service.Timeout)
+		if err != nil {
+			return err
+		}
+
+		s.timeout = t
+	}
+
+	// a new connection is used for every request to hit
+	// the load balancing of the Service.
+	s.client = &http.Client{
+		Timeout: s.timeout,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+		},
+	}
+
+	return nil
+}
+
+// Name ...
+func (s *serviceProbe) Name() string {
+	return s.name
+}
+
+// Reset ...
+func (s *serviceProbe) Reset() {
+	s.serviceStats = NewServiceStats(s.name, s.nodeName)
+}
+
+// Collect ...
+func (s *serviceProbe) Collect(ch chan<- Metric) {
+	s.serviceStats.Collect(ch)
+}
+
+// AddStat ...
+func (s *serviceProbe) AddStat(stat serviceStat) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.serviceStats.values = append(s.serviceStats.values, stat)
+}
+
+// Do ...
+func (s *serviceProbe) Do(ctx context.Context, metrics Gatherer) func() error {
+	return func() error {
+		err := s.configure(s.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(s.opts.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				s.do(ctx)
+
+				metrics.Gather(s)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+func (s *serviceProbe) do(ctx context.Context) {
+	s.Reset()
+
+	for path, target := range s.targets {
+		path, target := path, target
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			s.AddStat(s.request(ctx, path, target))
+		}()
+	}
+
+	s.wg.Wait()
+}
+
+func (s *serviceProbe) request(ctx context.Context, path, target string) serviceStat {
+	stat := serviceStat{path: path, target: target}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/api/v1/instance", target), nil)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+
+	start := time.Now()
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	stat.time = time.Since(start)
+
+	if res.StatusCode != http.StatusOK {
+		stat.err = fmt.Errorf("%w: %d", ErrHTTPStatus, res.StatusCode)
+		return stat
+	}
+
+	instance := Instance{}
+	err = json.NewDecoder(res.Body).Decode(&instance)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+
+	stat.backendNode = instance.Node
+
+	return stat
+}
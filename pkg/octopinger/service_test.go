@@ -0,0 +1,55 @@
+package octopinger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceProbeRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/instance", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(Instance{Node: "backend"})
+	}))
+	defer ts.Close()
+
+	p := NewServiceProbe("monalisa")
+	assert.NoError(t, p.configure(&v1alpha1.Config{}))
+
+	stat := p.request(context.Background(), ServicePathClusterIP, strings.TrimPrefix(ts.URL, "http://"))
+	assert.NoError(t, stat.err)
+	assert.Equal(t, "backend", stat.backendNode)
+}
+
+func TestServiceProbeTargets(t *testing.T) {
+	p := NewServiceProbe("monalisa", WithServiceIP("10.96.0.10"), WithServiceName("octopinger-service.default.svc"))
+
+	assert.Equal(t, "10.96.0.10:8081", p.targets[ServicePathClusterIP])
+	assert.Equal(t, "octopinger-service.default.svc:8081", p.targets[ServicePathDNS])
+}
+
+func TestServiceStatsWrite(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	stats := NewServiceStats("service", "monalisa")
+	stats.values = []serviceStat{{path: "dns", target: "http://octopinger-service", backendNode: "octocat", time: 100}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_service_time"))
+
+	// a failed request does not keep the time of the last one
+	stats = NewServiceStats("service", "monalisa")
+	stats.values = []serviceStat{{path: "dns", target: "http://octopinger-service", err: context.DeadlineExceeded}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 0, testutil.CollectAndCount(m, "octopinger_probe_service_time"))
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_service_error_total"))
+}
@@ -0,0 +1,328 @@
+package octopinger
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+const (
+	// TCPErrorRefused is the error class of a refused connection.
+	TCPErrorRefused = "refused"
+	// TCPErrorTimeout is the error class of a timed out connection.
+	TCPErrorTimeout = "timeout"
+	// TCPErrorUnreachable is the error class of an unreachable host or network.
+	TCPErrorUnreachable = "unreachable"
+	// TCPErrorResolve is the error class of a host that could not be resolved.
+	TCPErrorResolve = "resolve"
+	// TCPErrorUnknown is the error class of all other errors.
+	TCPErrorUnknown = "unknown"
+)
+
+// TCPErrorClass returns the class of a connection error.
+func TCPErrorClass(err error) string {
+	var dnsErr *net.DNSError
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return TCPErrorRefused
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return TCPErrorUnreachable
+	case errors.As(err, &dnsErr):
+		return TCPErrorResolve
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return TCPErrorTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return TCPErrorTimeout
+	}
+
+	return TCPErrorUnknown
+}
+
+type tcpStat struct {
+	target      string
+	targetNode  string
+	targetZone  string
+	network     string
+	ipFamily    string
+	connectTime float64
+	err         error
+}
+
+type tcpStats struct {
+	values []tcpStat
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (t *tcpStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeTCP(t.nodeName)
+
+	results := make([]Result, 0, len(t.values))
+
+	for _, v := range t.values {
+		result := Result{
+			Target:     v.target,
+			TargetNode: v.targetNode,
+			TargetZone: v.targetZone,
+			Network:    v.network,
+			IPFamily:   v.ipFamily,
+		}
+
+		if v.err != nil {
+			monitor.IncProbeTCPError(t.nodeName, v.target, v.targetNode, v.ipFamily, TCPErrorClass(v.err))
+
+			result.Loss = 1
+			result.Error = v.err.Error()
+		} else {
+			monitor.IncProbeTCPSuccess(t.nodeName, v.target, v.targetNode, v.ipFamily)
+			monitor.SetProbeTCPConnectTime(t.nodeName, v.target, v.targetNode, v.ipFamily, v.connectTime)
+			monitor.ObserveProbeTCPConnectTime(t.nodeName, v.target, v.ipFamily, v.connectTime)
+
+			result.RttMin = v.connectTime
+			result.RttMean = v.connectTime
+			result.RttMax = v.connectTime
+		}
+
+		results = append(results, result)
+	}
+
+	monitor.SetProbeResults(t.nodeName, t.probeName, results)
+
+	return nil
+}
+
+// Collect ...
+func (t *tcpStats) Collect(ch chan<- Metric) {
+	ch <- t
+}
+
+// NewTCPStats ...
+func NewTCPStats(probeName, nodeName string) *tcpStats {
+	return &tcpStats{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+type tcpProbe struct {
+	opts *Opts
+
+	name     string
+	nodeName string
+	targets  []string
+
+	tcpStats *tcpStats
+
+	timeout  time.Duration
+	nodePort int
+
+	maxConcurrency int
+
+	sem chan token
+	wg  sync.WaitGroup
+	mux sync.Mutex
+}
+
+// NewTCPProbe ...
+func NewTCPProbe(nodeName string, targets []string, opts ...Opt) *tcpProbe {
+	options := new(Opts)
+	options.Configure(opts...)
+
+	t := new(tcpProbe)
+	t.opts = options
+	t.name = "tcp"
+	t.nodeName = nodeName
+	t.targets = targets
+	t.timeout = defaultTimeout
+	t.maxConcurrency = 100
+	t.sem = make(chan token, t.maxConcurrency)
+
+	t.Reset()
+
+	return t
+}
+
+func (t *tcpProbe) configure(c *v1alpha1.Config) error {
+	if c.TCP.Timeout != "" {
+		s, err := time.ParseDuration(c.TCP.Timeout)
+		if err != nil {
+			return err
+		}
+
+		t.timeout = s
+	}
+
+	if c.TCP.NodePort > 0 {
+		t.nodePort = c.TCP.NodePort
+	}
+
+	return nil
+}
+
+// Name ...
+func (t *tcpProbe) Name() string {
+	return t.name
+}
+
+// Reset ...
+func (t *tcpProbe) Reset() {
+	t.tcpStats = NewTCPStats(t.name, t.nodeName)
+}
+
+// Collect ...
+func (t *tcpProbe) Collect(ch chan<- Metric) {
+	t.tcpStats.Collect(ch)
+}
+
+// AddStat ...
+func (t *tcpProbe) AddStat(target Target, connectTime time.Duration, err error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.tcpStats.values = append(t.tcpStats.values, tcpStat{
+		target:      target.Address(),
+		targetNode:  target.Node,
+		targetZone:  target.Zone,
+		ipFamily:    string(target.Family),
+		network:     string(target.Network),
+		connectTime: float64(connectTime.Microseconds()),
+		err:         err,
+	})
+}
+
+// Do ...
+func (t *tcpProbe) Do(ctx context.Context, metrics Gatherer) func() error {
+	return func() error {
+		err := t.configure(t.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(t.opts.interval)
+		defer ticker.Stop()
+
+		loaders := []NodeLoader{
+			NodesLoader(t.opts.configPath),
+		}
+
+		filters := []NodeFilter{
+			FilterNode(t.nodeName),
+			FilterIP(t.opts.ownIPs()...),
+			FilterNetwork(t.opts.config.Network),
+			FilterIPFamily(t.opts.ipFamilies...),
+		}
+
+		nodeList := NewNodeList(loaders, filters...)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				targets := make([]Target, 0, len(t.targets))
+				for _, target := range t.targets {
+					targets = append(targets, addressTargets(target, t.opts.ipFamilies)...)
+				}
+
+				if t.nodePort > 0 {
+					nodes, err := nodeList.Load()
+					if err != nil {
+						return err
+					}
+
+					for _, node := range nodes {
+						node.Port = t.nodePort
+						targets = append(targets, node)
+					}
+				}
+
+				t.do(ctx, targets...)
+
+				metrics.Gather(t)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+func (t *tcpProbe) do(ctx context.Context, targets ...Target) {
+	t.Reset()
+
+	for _, target := range targets {
+		target := target
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+
+			t.sem <- token{}
+
+			start := time.Now()
+			err := t.connect(ctx, FamilyNetwork("tcp", target.Family), target.Address())
+			t.AddStat(target, time.Since(start), err)
+
+			<-t.sem
+		}()
+	}
+
+	t.wg.Wait()
+}
+
+func (t *tcpProbe) connect(ctx context.Context, network, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	d := net.Dialer{}
+
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// addressTargets returns the targets of a host:port address for each family.
+// An address with an IP is only probed in the family of the IP.
+func addressTargets(address string, families []v1alpha1.IPFamily) []Target {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return []Target{{IP: address}}
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return []Target{{IP: address}}
+	}
+
+	target := Target{IP: host, Port: p, Family: IPFamilyOf(host)}
+	if target.Family != "" || len(families) == 0 {
+		return []Target{target}
+	}
+
+	targets := make([]Target, 0, len(families))
+	for _, f := range families {
+		target.Family = f
+		targets = append(targets, target)
+	}
+
+	return targets
+}
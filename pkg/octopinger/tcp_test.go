@@ -0,0 +1,75 @@
+package octopinger
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTCPErrorClass(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	addr := l.Addr().String()
+	assert.NoError(t, l.Close())
+
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+	assert.Equal(t, TCPErrorRefused, TCPErrorClass(err))
+
+	assert.Equal(t, TCPErrorTimeout, TCPErrorClass(context.DeadlineExceeded))
+	assert.Equal(t, TCPErrorResolve, TCPErrorClass(&net.DNSError{Err: "no such host", Name: "monalisa"}))
+}
+
+func TestTCPProbeConnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	port := l.Addr().(*net.TCPAddr).Port
+
+	p := NewTCPProbe("monalisa", nil)
+	p.do(context.Background(), Target{IP: "127.0.0.1", Port: port, Family: v1alpha1.IPFamilyIPv4})
+
+	assert.Len(t, p.tcpStats.values, 1)
+	assert.NoError(t, p.tcpStats.values[0].err)
+	assert.Equal(t, l.Addr().String(), p.tcpStats.values[0].target)
+}
+
+func TestAddressTargets(t *testing.T) {
+	families := []v1alpha1.IPFamily{v1alpha1.IPFamilyIPv4, v1alpha1.IPFamilyIPv6}
+
+	assert.Equal(t, []Target{
+		{IP: "www.ionos.com", Port: 443, Family: v1alpha1.IPFamilyIPv4},
+		{IP: "www.ionos.com", Port: 443, Family: v1alpha1.IPFamilyIPv6},
+	}, addressTargets("www.ionos.com:443", families))
+	assert.Equal(t, []Target{{IP: "fd00::1", Port: 443, Family: v1alpha1.IPFamilyIPv6}}, addressTargets("[fd00::1]:443", families))
+	assert.Equal(t, []Target{{IP: "www.ionos.com", Port: 443}}, addressTargets("www.ionos.com:443", nil))
+	assert.Equal(t, []Target{{IP: "10.0.0.1", Port: 443, Family: v1alpha1.IPFamilyIPv4}}, addressTargets("10.0.0.1:443", nil))
+
+	assert.Equal(t, "[fd00::1]:443", Target{IP: "fd00::1", Port: 443}.Address())
+	assert.Equal(t, "10.0.0.1", Target{IP: "10.0.0.1"}.Address())
+}
+
+func TestTCPStatsWrite(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	stats := NewTCPStats("tcp", "monalisa")
+	stats.values = []tcpStat{{target: "10.0.0.1:80", targetNode: "octocat", ipFamily: "ipv4", connectTime: 100}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_tcp_connect_time"))
+
+	// a failed connect does not keep the time of the last one
+	stats = NewTCPStats("tcp", "monalisa")
+	stats.values = []tcpStat{{target: "10.0.0.1:80", targetNode: "octocat", ipFamily: "ipv4", err: context.DeadlineExceeded}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 0, testutil.CollectAndCount(m, "octopinger_probe_tcp_connect_time"))
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_tcp_error_total"))
+}
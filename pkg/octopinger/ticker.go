@@ -0,0 +1,70 @@
+package octopinger
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// DefaultInterval is the default time between two rounds of a probe.
+const DefaultInterval = 1 * time.Second
+
+// splayTicker is firing in an interval plus a random splay,
+// so that the instances of Octopinger are not probing at the same time.
+type splayTicker struct {
+	C <-chan time.Time
+
+	interval time.Duration
+	timer    *time.Timer
+}
+
+// NewSplayTicker returns a ticker firing after a random splay of up to the
+// interval. Every reset fires after the interval plus a random splay of up
+// to a tenth of the interval.
+func NewSplayTicker(interval time.Duration) *splayTicker {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	t := new(splayTicker)
+	t.interval = interval
+	t.timer = time.NewTimer(splay(interval))
+	t.C = t.timer.C
+
+	return t
+}
+
+// Reset ...
+func (t *splayTicker) Reset() {
+	t.timer.Reset(t.interval + splay(t.interval/10))
+}
+
+// Stop ...
+func (t *splayTicker) Stop() {
+	t.timer.Stop()
+}
+
+func parseInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultInterval, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("interval must be positive: %s", s)
+	}
+
+	return d, nil
+}
+
+func splay(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(d)))
+}
@@ -0,0 +1,39 @@
+package octopinger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplay(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		s := splay(time.Second)
+		assert.GreaterOrEqual(t, s, time.Duration(0))
+		assert.Less(t, s, time.Second)
+	}
+
+	assert.Equal(t, time.Duration(0), splay(0))
+}
+
+func TestNewSplayTicker(t *testing.T) {
+	ticker := NewSplayTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	select {
+	case <-ticker.C:
+	case <-time.After(time.Second):
+		assert.Fail(t, "ticker did not fire")
+	}
+
+	ticker.Reset()
+
+	select {
+	case <-ticker.C:
+	case <-time.After(time.Second):
+		assert.Fail(t, "ticker did not fire after reset")
+	}
+
+	assert.Equal(t, DefaultInterval, NewSplayTicker(0).interval)
+}
@@ -0,0 +1,355 @@
+package octopinger
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+)
+
+const (
+	// DefaultAPIServerAddress is the in-cluster address of the Kubernetes API server.
+	DefaultAPIServerAddress = "kubernetes.default.svc:443"
+	// DefaultServiceAccountCAFile is the path to the CA of the Kubernetes API server.
+	DefaultServiceAccountCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	// TLSCADir is the directory of the ConfigMaps with the certificate authorities of the TLS targets
+	// in the Octopinger pod. Each ConfigMap is mounted into a directory of its name.
+	TLSCADir = "/etc/octopinger/tls-ca"
+)
+
+// ErrNoCertificates ...
+var ErrNoCertificates = errors.New("no peer certificates")
+
+type tlsCheck struct {
+	address    string
+	serverName string
+	roots      *x509.CertPool
+	timeout    time.Duration
+}
+
+// TLSCAFile returns the path of the certificate authorities of a TLS target in the directory
+// of the mounted ConfigMaps, or an empty path if the target is verified against the system roots.
+func TLSCAFile(dir string, target v1alpha1.TLSTarget) string {
+	if target.CAConfigMap == nil {
+		return ""
+	}
+
+	return filepath.Join(dir, target.CAConfigMap.Name, target.CAConfigMap.Key)
+}
+
+// NewTLSCheck returns the check of a target, whose chain is verified against the certificate authorities
+// of the file, or the system roots if the file is empty.
+func NewTLSCheck(target v1alpha1.TLSTarget, caFile string) (*tlsCheck, error) {
+	c := new(tlsCheck)
+	c.address = target.Address
+	c.serverName = target.ServerName
+	c.timeout = defaultTimeout
+
+	if c.serverName == "" {
+		host, _, err := net.SplitHostPort(target.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		c.serverName = host
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		c.roots = x509.NewCertPool()
+		if !c.roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+	}
+
+	if target.Timeout != "" {
+		s, err := time.ParseDuration(target.Timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		c.timeout = s
+	}
+
+	return c, nil
+}
+
+type tlsStat struct {
+	target          string
+	serverName      string
+	version         string
+	cipher          string
+	certExpiryDays  float64
+	chainExpiryDays float64
+	chainValid      bool
+	sniMismatch     bool
+	handshakeTime   time.Duration
+	err             error
+}
+
+type tlsStats struct {
+	values []tlsStat
+
+	probeName string
+	nodeName  string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (t *tlsStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeTLS(t.nodeName)
+
+	results := make([]Result, 0, len(t.values))
+
+	for _, v := range t.values {
+		result := Result{
+			Target: v.target,
+		}
+
+		if v.err != nil {
+			monitor.IncProbeTLSError(t.nodeName, v.target, v.serverName, HTTPErrorClass(v.err))
+
+			result.Loss = 1
+			result.Error = v.err.Error()
+			results = append(results, result)
+
+			continue
+		}
+
+		monitor.SetProbeTLSCertExpiryDays(t.nodeName, v.target, v.serverName, v.certExpiryDays)
+		monitor.SetProbeTLSChainExpiryDays(t.nodeName, v.target, v.serverName, v.chainExpiryDays)
+		monitor.SetProbeTLSChainValid(t.nodeName, v.target, v.serverName, v.chainValid)
+		monitor.SetProbeTLSSNIMismatch(t.nodeName, v.target, v.serverName, v.sniMismatch)
+		monitor.SetProbeTLSInfo(t.nodeName, v.target, v.serverName, v.version, v.cipher)
+
+		result.RttMin = float64(v.handshakeTime.Microseconds())
+		result.RttMean = float64(v.handshakeTime.Microseconds())
+		result.RttMax = float64(v.handshakeTime.Microseconds())
+
+		results = append(results, result)
+	}
+
+	monitor.SetProbeResults(t.nodeName, t.probeName, results)
+
+	return nil
+}
+
+// Collect ...
+func (t *tlsStats) Collect(ch chan<- Metric) {
+	ch <- t
+}
+
+// NewTLSStats ...
+func NewTLSStats(probeName, nodeName string) *tlsStats {
+	return &tlsStats{
+		probeName: probeName,
+		nodeName:  nodeName,
+	}
+}
+
+type tlsProbe struct {
+	opts *Opts
+
+	name     string
+	nodeName string
+	checks   []*tlsCheck
+	caDir    string
+
+	tlsStats *tlsStats
+
+	maxConcurrency int
+
+	sem chan token
+	wg  sync.WaitGroup
+	mux sync.Mutex
+}
+
+// NewTLSProbe ...
+func NewTLSProbe(nodeName string, opts ...Opt) *tlsProbe {
+	options := new(Opts)
+	options.Configure(opts...)
+
+	t := new(tlsProbe)
+	t.opts = options
+	t.name = "tls"
+	t.nodeName = nodeName
+	t.caDir = TLSCADir
+	t.maxConcurrency = 100
+	t.sem = make(chan token, t.maxConcurrency)
+
+	t.Reset()
+
+	return t
+}
+
+func (t *tlsProbe) configure(c *v1alpha1.Config) error {
+	checks := make([]*tlsCheck, 0, len(c.TLS.Targets)+1)
+
+	for _, target := range c.TLS.Targets {
+		check, err := NewTLSCheck(target, TLSCAFile(t.caDir, target))
+		if err != nil {
+			return err
+		}
+
+		checks = append(checks, check)
+	}
+
+	if c.TLS.APIServer {
+		check, err := NewTLSCheck(v1alpha1.TLSTarget{Address: DefaultAPIServerAddress}, DefaultServiceAccountCAFile)
+		if err != nil {
+			return err
+		}
+
+		checks = append(checks, check)
+	}
+
+	t.checks = checks
+
+	return nil
+}
+
+// Name ...
+func (t *tlsProbe) Name() string {
+	return t.name
+}
+
+// Reset ...
+func (t *tlsProbe) Reset() {
+	t.tlsStats = NewTLSStats(t.name, t.nodeName)
+}
+
+// Collect ...
+func (t *tlsProbe) Collect(ch chan<- Metric) {
+	t.tlsStats.Collect(ch)
+}
+
+// AddStat ...
+func (t *tlsProbe) AddStat(stat tlsStat) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.tlsStats.values = append(t.tlsStats.values, stat)
+}
+
+// Do ...
+func (t *tlsProbe) Do(ctx context.Context, metrics Gatherer) func() error {
+	return func() error {
+		err := t.configure(t.opts.config)
+		if err != nil {
+			return err
+		}
+
+		ticker := NewSplayTicker(t.opts.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				t.do(ctx, t.checks...)
+
+				metrics.Gather(t)
+				ticker.Reset()
+
+				continue
+			}
+		}
+	}
+}
+
+func (t *tlsProbe) do(ctx context.Context, checks ...*tlsCheck) {
+	t.Reset()
+
+	for _, check := range checks {
+		check := check
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+
+			t.sem <- token{}
+
+			t.AddStat(t.handshake(ctx, check))
+
+			<-t.sem
+		}()
+	}
+
+	t.wg.Wait()
+}
+
+func (t *tlsProbe) handshake(ctx context.Context, check *tlsCheck) tlsStat {
+	stat := tlsStat{target: check.address, serverName: check.serverName}
+
+	ctx, cancel := context.WithTimeout(ctx, check.timeout)
+	defer cancel()
+
+	d := tls.Dialer{
+		Config: &tls.Config{
+			ServerName: check.serverName,
+			// the chain is verified after the handshake to report
+			// on expired or invalid certificates.
+			InsecureSkipVerify: true, //nolint:gosec
+		},
+	}
+
+	start := time.Now()
+
+	conn, err := d.DialContext(ctx, "tcp", check.address)
+	if err != nil {
+		stat.err = err
+		return stat
+	}
+	defer func() { _ = conn.Close() }()
+
+	stat.handshakeTime = time.Since(start)
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		stat.err = ErrNoCertificates
+		return stat
+	}
+
+	now := time.Now()
+	leaf := state.PeerCertificates[0]
+
+	stat.version = tls.VersionName(state.Version)
+	stat.cipher = tls.CipherSuiteName(state.CipherSuite)
+	stat.certExpiryDays = leaf.NotAfter.Sub(now).Hours() / 24
+	stat.sniMismatch = leaf.VerifyHostname(check.serverName) != nil
+
+	stat.chainExpiryDays = math.Inf(1)
+	for _, cert := range state.PeerCertificates {
+		stat.chainExpiryDays = math.Min(stat.chainExpiryDays, cert.NotAfter.Sub(now).Hours()/24)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         check.roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	stat.chainValid = err == nil
+
+	return stat
+}
@@ -0,0 +1,81 @@
+package octopinger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ionos-cloud/octopinger/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTLSProbeHandshake(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	address := strings.TrimPrefix(ts.URL, "https://")
+
+	check, err := NewTLSCheck(v1alpha1.TLSTarget{Address: address, ServerName: "example.com"}, "")
+	assert.NoError(t, err)
+
+	p := NewTLSProbe("monalisa")
+	stat := p.handshake(context.Background(), check)
+
+	assert.NoError(t, stat.err)
+	assert.False(t, stat.chainValid)
+	assert.False(t, stat.sniMismatch)
+	assert.Greater(t, stat.certExpiryDays, 0.0)
+	assert.NotEmpty(t, stat.version)
+	assert.NotEmpty(t, stat.cipher)
+
+	check, err = NewTLSCheck(v1alpha1.TLSTarget{Address: address, ServerName: "monalisa.local"}, "")
+	assert.NoError(t, err)
+
+	stat = p.handshake(context.Background(), check)
+	assert.NoError(t, stat.err)
+	assert.True(t, stat.sniMismatch)
+}
+
+func TestNewTLSCheckCAFile(t *testing.T) {
+	dir := t.TempDir()
+	target := v1alpha1.TLSTarget{
+		Address:     "kubernetes.default.svc:443",
+		CAConfigMap: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "tls-ca"}, Key: "ca.crt"},
+	}
+
+	caFile := TLSCAFile(dir, target)
+	assert.Equal(t, filepath.Join(dir, "tls-ca", "ca.crt"), caFile)
+	assert.Empty(t, TLSCAFile(dir, v1alpha1.TLSTarget{Address: target.Address}))
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(caFile), 0o700))
+	assert.NoError(t, os.WriteFile(caFile, []byte("no certificate"), 0o600))
+
+	_, err := NewTLSCheck(target, caFile)
+	assert.Error(t, err)
+}
+
+func TestTLSStatsWrite(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	stats := NewTLSStats("tls", "monalisa")
+	stats.values = []tlsStat{{target: "10.0.0.1:443", serverName: "monalisa.local", chainValid: true, certExpiryDays: 30}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_tls_chain_valid"))
+
+	// a failed handshake does not keep the values of the last one
+	stats = NewTLSStats("tls", "monalisa")
+	stats.values = []tlsStat{{target: "10.0.0.1:443", serverName: "monalisa.local", err: ErrNoCertificates}}
+	assert.NoError(t, stats.Write(monitor))
+
+	assert.Equal(t, 0, testutil.CollectAndCount(m, "octopinger_probe_tls_chain_valid"))
+	assert.Equal(t, 0, testutil.CollectAndCount(m, "octopinger_probe_tls_cert_expiry_days"))
+	assert.Equal(t, 1, testutil.CollectAndCount(m, "octopinger_probe_tls_error_total"))
+}
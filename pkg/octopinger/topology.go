@@ -0,0 +1,175 @@
+package octopinger
+
+import (
+	"sort"
+)
+
+// Topology is the zone and region of a node.
+type Topology struct {
+	// Zone is the topology zone of the node.
+	Zone string
+	// Region is the topology region of the node.
+	Region string
+}
+
+// ZoneLink is a directed connection between the zones of two nodes.
+type ZoneLink struct {
+	// Source is the topology of the probing node.
+	Source Topology
+	// Target is the topology of the probed nodes.
+	Target Topology
+	// Network is the network of the probed addresses.
+	Network string
+	// IPFamily is the IP family of the probed addresses.
+	IPFamily string
+}
+
+// IsInterZone returns true if the link connects two different zones.
+func (l ZoneLink) IsInterZone() bool {
+	return l.Source != l.Target
+}
+
+func (l ZoneLink) labelValues(probe string) []string {
+	return []string{probe, l.Source.Zone, l.Source.Region, l.Target.Zone, l.Target.Region, l.Network, l.IPFamily}
+}
+
+// ZoneResult is the result of a probe aggregated over all targets in a zone.
+type ZoneResult struct {
+	ZoneLink
+
+	// Targets is the number of probed targets in the zone.
+	Targets int
+	// Loss is the mean fraction of lost packets.
+	Loss float64
+	// RttMean is the mean round-trip time in microseconds.
+	RttMean float64
+	// RttMax is the max round-trip time in microseconds.
+	RttMax float64
+	// Degraded is set if the loss to another zone is above the threshold,
+	// while the loss within the zone of the probing node is not.
+	Degraded bool
+}
+
+// topologyOf returns the topology of a node in the targets.
+func topologyOf(targets []Target, nodeName string) Topology {
+	for _, t := range targets {
+		if t.Node == nodeName {
+			return Topology{Zone: t.Zone, Region: t.Region}
+		}
+	}
+
+	return Topology{}
+}
+
+// aggregateZones is aggregating the results of the targets by zone.
+// Targets without a zone are omitted.
+func aggregateZones(source Topology, values []targetStat, threshold float64) []ZoneResult {
+	if source.Zone == "" {
+		return nil
+	}
+
+	index := make(map[ZoneLink]int)
+	results := make([]ZoneResult, 0)
+
+	for _, v := range values {
+		if v.targetZone == "" {
+			continue
+		}
+
+		link := ZoneLink{
+			Source:   source,
+			Target:   Topology{Zone: v.targetZone, Region: v.targetRegion},
+			Network:  v.network,
+			IPFamily: v.ipFamily,
+		}
+
+		i, ok := index[link]
+		if !ok {
+			i = len(results)
+			index[link] = i
+			results = append(results, ZoneResult{ZoneLink: link})
+		}
+
+		r := &results[i]
+		r.Targets++
+		r.Loss += v.packetLoss
+		r.RttMean += v.meanRtt
+
+		if v.maxRtt > r.RttMax {
+			r.RttMax = v.maxRtt
+		}
+	}
+
+	for i := range results {
+		results[i].Loss /= float64(results[i].Targets)
+		results[i].RttMean /= float64(results[i].Targets)
+	}
+
+	for i, r := range results {
+		if !r.IsInterZone() || r.Loss <= threshold {
+			continue
+		}
+
+		// without other nodes in the zone of the probing node, it is unknown whether the zone is healthy
+		j, ok := index[ZoneLink{Source: source, Target: source, Network: r.Network, IPFamily: r.IPFamily}]
+		results[i].Degraded = ok && results[j].Loss <= threshold
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Target != results[j].Target {
+			if results[i].Target.Region != results[j].Target.Region {
+				return results[i].Target.Region < results[j].Target.Region
+			}
+
+			return results[i].Target.Zone < results[j].Target.Zone
+		}
+
+		if results[i].Network != results[j].Network {
+			return results[i].Network < results[j].Network
+		}
+
+		return results[i].IPFamily < results[j].IPFamily
+	})
+
+	return results
+}
+
+type zoneStats struct {
+	values []targetStat
+
+	source    Topology
+	threshold float64
+
+	probeName string
+
+	Metric
+	Collector
+}
+
+// Write ...
+func (m *zoneStats) Write(monitor *Monitor) error {
+	monitor.ResetProbeZones(m.probeName)
+
+	for _, r := range aggregateZones(m.source, m.values, m.threshold) {
+		monitor.SetProbeZoneLoss(m.probeName, r.ZoneLink, r.Loss)
+		monitor.SetProbeZoneRttMean(m.probeName, r.ZoneLink, r.RttMean)
+		monitor.SetProbeZoneRttMax(m.probeName, r.ZoneLink, r.RttMax)
+		monitor.SetProbeZoneDegraded(m.probeName, r.ZoneLink, r.Degraded)
+	}
+
+	return nil
+}
+
+// Collect ...
+func (m *zoneStats) Collect(ch chan<- Metric) {
+	ch <- m
+}
+
+// NewZoneStats ...
+func NewZoneStats(probeName string, source Topology, threshold float64) *zoneStats {
+	return &zoneStats{
+		probeName: probeName,
+		source:    source,
+		threshold: threshold,
+	}
+}
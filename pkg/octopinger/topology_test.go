@@ -0,0 +1,96 @@
+package octopinger
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateZones(t *testing.T) {
+	a := Topology{Zone: "de-fra-1", Region: "de-fra"}
+	b := Topology{Zone: "de-fra-2", Region: "de-fra"}
+
+	values := []targetStat{
+		{targetZone: a.Zone, targetRegion: a.Region, network: "host", packetLoss: 0, meanRtt: 100, maxRtt: 200},
+		{targetZone: b.Zone, targetRegion: b.Region, network: "host", packetLoss: 0.5, meanRtt: 300, maxRtt: 900},
+		{targetZone: b.Zone, targetRegion: b.Region, network: "host", packetLoss: 0.25, meanRtt: 500, maxRtt: 700},
+		{network: "host", packetLoss: 1},
+	}
+
+	results := aggregateZones(a, values, 0.05)
+	assert.Equal(t, []ZoneResult{
+		{ZoneLink: ZoneLink{Source: a, Target: a, Network: "host"}, Targets: 1, Loss: 0, RttMean: 100, RttMax: 200},
+		{ZoneLink: ZoneLink{Source: a, Target: b, Network: "host"}, Targets: 2, Loss: 0.375, RttMean: 400, RttMax: 900, Degraded: true},
+	}, results)
+
+	// the intra zone link is degraded, too
+	values[0].packetLoss = 0.25
+	results = aggregateZones(a, values, 0.05)
+	assert.False(t, results[1].Degraded)
+
+	// the zone of the probing node is unknown
+	assert.Empty(t, aggregateZones(Topology{}, values, 0.05))
+}
+
+func TestTopologyOf(t *testing.T) {
+	targets := []Target{
+		{IP: "10.0.0.1", Node: "node-a", Zone: "de-fra-1", Region: "de-fra"},
+		{IP: "10.0.0.2", Node: "node-b", Zone: "de-fra-2", Region: "de-fra"},
+	}
+
+	assert.Equal(t, Topology{Zone: "de-fra-2", Region: "de-fra"}, topologyOf(targets, "node-b"))
+	assert.Equal(t, Topology{}, topologyOf(targets, "node-c"))
+}
+
+func TestZoneStatsWrite(t *testing.T) {
+	m := NewMetrics()
+	monitor := NewMonitor(m)
+
+	stats := NewZoneStats("icmp", Topology{Zone: "de-fra-1", Region: "de-fra"}, 0.05)
+	stats.values = []targetStat{
+		{targetZone: "de-fra-2", targetRegion: "de-fra", network: "host", ipFamily: "IPv4", packetLoss: 0.5, meanRtt: 300, maxRtt: 900},
+	}
+
+	assert.NoError(t, stats.Write(monitor))
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m)
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	for _, f := range families {
+		if f.GetName() != "octopinger_probe_zone_loss" {
+			continue
+		}
+
+		assert.Len(t, f.GetMetric(), 1)
+
+		labels := map[string]string{}
+		for _, l := range f.GetMetric()[0].GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+
+		assert.Equal(t, map[string]string{
+			"octopinger_probe":          "icmp",
+			"octopinger_zone":           "de-fra-1",
+			"octopinger_region":         "de-fra",
+			"octopinger_target_zone":    "de-fra-2",
+			"octopinger_target_region":  "de-fra",
+			"octopinger_target_network": "host",
+			"octopinger_ip_family":      "IPv4",
+		}, labels)
+		assert.Equal(t, 0.5, f.GetMetric()[0].GetGauge().GetValue())
+	}
+
+	// a probe removes the series of its zones
+	monitor.ResetProbeZones("icmp")
+
+	families, err = reg.Gather()
+	assert.NoError(t, err)
+
+	for _, f := range families {
+		assert.NotEqual(t, "octopinger_probe_zone_loss", f.GetName())
+	}
+}